@@ -2,6 +2,8 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"log"
 	"os"
@@ -13,15 +15,21 @@ import (
 
 	"market/internal/config"
 	"market/internal/db"
+	"market/internal/domain"
+	"market/internal/events"
 	"market/internal/handler"
 	"market/internal/logger"
+	"market/internal/mailer"
+	"market/internal/message_broker"
 	"market/internal/middleware"
 	"market/internal/repository"
 	"market/internal/service"
+	"market/internal/utils"
 
 	"github.com/gofiber/fiber/v2"
 	flogger "github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func main() {
@@ -66,6 +74,7 @@ func main() {
 		Prefork:      cfg.Server.Prefork,
 		ReadTimeout:  cfg.Server.ReadTimeout,
 		WriteTimeout: cfg.Server.WriteTimeout,
+		ProxyHeader:  cfg.Server.ProxyHeader,
 		AppName:      "Marketplace API",
 		ErrorHandler: func(c *fiber.Ctx, e error) error {
 			var fe *fiber.Error
@@ -77,28 +86,279 @@ func main() {
 		},
 	})
 	app.Use(recover.New())
+	app.Use(requestid.New())
 	app.Use(flogger.New())
+	app.Use(middleware.RequestMeta())
+
+	// JWT keys
+	keys := utils.NewHMACKeySet(cfg.Auth.JWTSecret)
+	if len(cfg.Auth.Keys) > 0 {
+		keyCfgs := make([]utils.KeyConfig, 0, len(cfg.Auth.Keys))
+		for _, k := range cfg.Auth.Keys {
+			keyCfgs = append(keyCfgs, utils.KeyConfig(k))
+		}
+		keys, err = utils.LoadKeySet(keyCfgs, cfg.Auth.ActiveKeyID)
+		if err != nil {
+			z.Fatalw("jwt keys load failed", "err", err)
+		}
+	}
+
+	tokenOpts := utils.JWTOptions{
+		Issuer:   cfg.Auth.Issuer,
+		Audience: cfg.Auth.Audience,
+		TTL:      cfg.Auth.AccessTTL,
+		Leeway:   cfg.Auth.Leeway,
+	}
+
+	passwords, err := utils.NewPasswordHasher(utils.PasswordConfig{
+		Algorithm:  cfg.Auth.Password.Algorithm,
+		BcryptCost: cfg.Auth.Password.BcryptCost,
+		Argon2: utils.Argon2Params{
+			Memory:      cfg.Auth.Password.Argon2Memory,
+			Iterations:  cfg.Auth.Password.Argon2Iterations,
+			Parallelism: cfg.Auth.Password.Argon2Threads,
+		},
+		MinLength:        cfg.Auth.Password.MinLength,
+		MaxLength:        cfg.Auth.Password.MaxLength,
+		BreachedListFile: cfg.Auth.Password.BreachedListFile,
+	})
+	if err != nil {
+		z.Fatalw("password hasher init failed", "err", err)
+	}
+
+	// Ключ шифрования TOTP-секретов
+	var mfaKey []byte
+	if cfg.Auth.MFA.EncryptionKey != "" {
+		mfaKey, err = base64.StdEncoding.DecodeString(cfg.Auth.MFA.EncryptionKey)
+		if err != nil {
+			z.Fatalw("auth.mfa.encryptionKey must be base64", "err", err)
+		}
+	} else {
+		z.Warnw("auth.mfa.encryptionKey is empty, deriving key from jwtSecret")
+		sum := sha256.Sum256([]byte("mfa:" + cfg.Auth.JWTSecret))
+		mfaKey = sum[:]
+	}
+	mfaBox, err := utils.NewSecretBox(mfaKey)
+	if err != nil {
+		z.Fatalw("mfa secret box init failed", "err", err)
+	}
+
+	// Kafka
+	registry := events.NewRegistry(cfg.Kafka.Topics, cfg.Kafka.TopicPrefix)
+	var producer *message_broker.Producer
+	if len(cfg.Kafka.Brokers) > 0 {
+		producer, err = message_broker.NewProducer(message_broker.ProducerConfig{
+			Brokers:        cfg.Kafka.Brokers,
+			BufferSize:     cfg.Kafka.ProducerBuffer,
+			Workers:        cfg.Kafka.ProducerWorkers,
+			MaxAttempts:    cfg.Kafka.MaxAttempts,
+			InitialBackoff: cfg.Kafka.RetryBackoff,
+			MaxBackoff:     cfg.Kafka.MaxRetryBackoff,
+			DLQSuffix:      cfg.Kafka.DLQSuffix,
+			Log:            z,
+		})
+		if err != nil {
+			z.Fatalw("kafka producer failed", "err", err)
+		}
+	}
+
 	// Repos
+	txm := repository.NewTxManager(pool)
+	outboxRepo := repository.NewOutboxRepository(pool)
 	userRepo := repository.NewUserRepository(pool)
+	refreshRepo := repository.NewRefreshTokenRepository(pool)
+	revocationRepo := repository.NewRevocationRepository(pool)
+	verificationRepo := repository.NewEmailVerificationRepository(pool)
+	passwordResetRepo := repository.NewPasswordResetRepository(pool)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(pool)
+	mfaRepo := repository.NewMFARepository(pool)
+	identityRepo := repository.NewIdentityRepository(pool)
+	sellerProfileRepo := repository.NewSellerProfileRepository(pool)
+	apiKeyRepo := repository.NewAPIKeyRepository(pool)
+	auditRepo := repository.NewAuditRepository(pool)
 	productRepo := repository.NewProductRepository(pool)
 	pictureRepo := repository.NewPictureRepository(pool)
+	balanceRepo := repository.NewBalanceRepository(pool)
+	cartRepo := repository.NewCartRepository(pool)
+	orderRepo := repository.NewOrderRepository(pool)
+
+	// Events
+	var publisher service.EventPublisher = outboxRepo
+	switch cfg.Events.Publisher {
+	case "outbox":
+	case "memory":
+		bus := events.NewMemoryBus()
+		bus.SubscribeAll(func(ctx context.Context, env events.Envelope) error {
+			z.Infow("event", "type", env.Type, "aggregate_id", env.AggregateID, "payload", string(env.Payload))
+			return nil
+		})
+		publisher = bus
+	case "kafka":
+		if producer == nil {
+			z.Fatalw("events.publisher=kafka requires kafka.brokers")
+		}
+		publisher = message_broker.NewKafkaPublisher(producer, registry)
+	default:
+		z.Fatalw("unknown events.publisher", "publisher", cfg.Events.Publisher)
+	}
+
+	// Mailer
+	var mail mailer.Mailer
+	switch cfg.Mailer.Driver {
+	case "log":
+		mail = mailer.NewLogMailer(z)
+	case "file":
+		fm, err := mailer.NewFileMailer(cfg.Mailer.Dir, cfg.Mailer.From)
+		if err != nil {
+			z.Fatalw("file mailer failed", "err", err)
+		}
+		mail = fm
+	default:
+		z.Fatalw("unknown mailer.driver", "driver", cfg.Mailer.Driver)
+	}
+
+	// Outbox relay: в prefork-режиме запускается только в родительском процессе
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	relayDone := make(chan struct{})
+	if cfg.Events.Publisher == "outbox" && producer != nil && !fiber.IsChild() {
+		relay := message_broker.NewRelay(outboxRepo, txm, producer, registry, message_broker.RelayConfig{
+			Interval:  cfg.Kafka.OutboxInterval,
+			BatchSize: cfg.Kafka.OutboxBatch,
+		}, z)
+		go func() {
+			defer close(relayDone)
+			relay.Run(relayCtx)
+		}()
+	} else {
+		close(relayDone)
+	}
 
 	// Services
-	authSvc := service.NewAuthService(userRepo, cfg.Auth.JWTSecret, cfg.Auth.AccessTTL)
-	productSvc := service.NewProductService(productRepo)
-	pictureSvc := service.NewPictureService(productRepo, pictureRepo)
+	jobs := service.NewBackground(cfg.Mailer.Timeout, z)
+	auditor := service.NewAuditor(auditRepo)
+	revocationSvc := service.NewRevocationService(revocationRepo, service.RevocationConfig{
+		CacheSize: cfg.Auth.RevocationCacheSize,
+	})
+	verificationSvc := service.NewEmailVerificationService(userRepo, verificationRepo, txm, mail, jobs, publisher, service.EmailVerificationConfig{
+		TTL:            cfg.Auth.EmailVerifyTTL,
+		ResendInterval: cfg.Auth.EmailVerifyResendInterval,
+		LinkURL:        cfg.Auth.EmailVerifyURL,
+	})
+	loginGuard := service.NewLoginGuard(loginAttemptRepo, txm, service.LoginGuardConfig{
+		Email:           service.LoginLimit{FreeAttempts: cfg.Auth.Login.EmailFreeAttempts, LockoutAfter: cfg.Auth.Login.EmailLockoutAfter},
+		IP:              service.LoginLimit{FreeAttempts: cfg.Auth.Login.IPFreeAttempts, LockoutAfter: cfg.Auth.Login.IPLockoutAfter},
+		Window:          cfg.Auth.Login.Window,
+		BaseDelay:       cfg.Auth.Login.BaseDelay,
+		MaxDelay:        cfg.Auth.Login.MaxDelay,
+		LockoutDuration: cfg.Auth.Login.LockoutDuration,
+	})
+	mfaSvc := service.NewMFAService(userRepo, mfaRepo, txm, mfaBox, loginGuard, passwords, auditor, service.MFAConfig{
+		Issuer:        cfg.Auth.MFA.Issuer,
+		RecoveryCodes: cfg.Auth.MFA.RecoveryCodes,
+	})
+	authSvc := service.NewAuthService(userRepo, refreshRepo, revocationSvc, verificationSvc, loginGuard, mfaSvc, txm, publisher, auditor, service.AuthConfig{
+		Keys:        keys,
+		Passwords:   passwords,
+		Token:       tokenOpts,
+		RefreshTTL:  cfg.Auth.RefreshTTL,
+		MFATokenTTL: cfg.Auth.MFA.TokenTTL,
+	})
+	passwordResetSvc := service.NewPasswordResetService(userRepo, passwordResetRepo, refreshRepo, revocationSvc, txm, mail, jobs, auditor, service.PasswordResetConfig{
+		Passwords: passwords,
+		TTL:       cfg.Auth.PasswordResetTTL,
+		LinkURL:   cfg.Auth.PasswordResetURL,
+	})
+	oidcProviders := make(map[string]service.OIDCProviderConfig, len(cfg.Auth.OIDC.Providers))
+	for name, p := range cfg.Auth.OIDC.Providers {
+		oidcProviders[name] = service.OIDCProviderConfig(p)
+	}
+	oidcSvc := service.NewOIDCService(userRepo, identityRepo, txm, publisher, authSvc, service.OIDCConfig{
+		Providers: oidcProviders,
+		StateTTL:  cfg.Auth.OIDC.StateTTL,
+	})
+	productSvc := service.NewProductService(productRepo, userRepo, txm, publisher, auditor)
+	pictureSvc := service.NewPictureService(productRepo, pictureRepo, txm, publisher, auditor)
+	userSvc := service.NewUserService(userRepo, pictureRepo, productRepo, sellerProfileRepo, identityRepo, mfaRepo, refreshRepo,
+		revocationSvc, verificationSvc, passwords, loginGuard, mail, jobs, txm, publisher, auditor, service.UserConfig{
+			ReauthMaxAge: cfg.Auth.ReauthMaxAge,
+		})
+	sellerSvc := service.NewSellerService(sellerProfileRepo, productRepo, userRepo, pictureRepo, txm, auditor)
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo, txm, auditor)
+	adminSvc := service.NewAdminService(userRepo, productRepo, refreshRepo, revocationSvc, txm, publisher, auditor)
+	walletSvc := service.NewWalletService(balanceRepo)
+	cartSvc := service.NewCartService(cartRepo, productRepo)
+	orderSvc := service.NewOrderService(orderRepo, cartRepo, txm, publisher)
+
+	// Первый администратор: в prefork-режиме только в родительском процессе
+	if !fiber.IsChild() && len(cfg.Auth.BootstrapAdmins) > 0 {
+		ctxBootstrap, cancelBootstrap := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelBootstrap()
+		for _, email := range cfg.Auth.BootstrapAdmins {
+			promoted, err := adminSvc.BootstrapAdmin(ctxBootstrap, email)
+			switch {
+			case errors.Is(err, repository.ErrUserNotFound):
+				z.Warnw("bootstrap admin: user not found, register first", "email", email)
+			case err != nil:
+				z.Fatalw("bootstrap admin failed", "email", email, "err", err)
+			case promoted:
+				z.Infow("bootstrap admin: role granted", "email", email)
+			}
+		}
+	}
 
+	// Очистка просроченных записей: как и relay, только в родительском процессе
+	janitorCtx, stopJanitor := context.WithCancel(context.Background())
+	janitorDone := make(chan struct{})
+	if !fiber.IsChild() {
+		janitor := service.NewJanitor(cfg.Auth.CleanupInterval, z)
+		janitor.Add("revoked_tokens", revocationSvc.DeleteExpired)
+		janitor.Add("login_attempts", loginGuard.DeleteExpired)
+		go func() {
+			defer close(janitorDone)
+			janitor.Run(janitorCtx)
+		}()
+	} else {
+		close(janitorDone)
+	}
 	// Handlers
 	authH := handler.NewAuthHandler(authSvc)
+	verifyH := handler.NewVerificationHandler(verificationSvc)
+	passwordH := handler.NewPasswordResetHandler(passwordResetSvc)
+	mfaH := handler.NewMFAHandler(mfaSvc)
+	oidcH := handler.NewOIDCHandler(oidcSvc)
 	prodH := handler.NewProductHandler(productSvc)
 	picH := handler.NewPictureHandler(pictureSvc)
+	userH := handler.NewUserHandler(userSvc)
+	sellerH := handler.NewSellerHandler(sellerSvc)
+	apiKeyH := handler.NewAPIKeyHandler(apiKeySvc)
+	adminH := handler.NewAdminHandler(adminSvc)
+	auditH := handler.NewAuditHandler(auditor)
+	walletH := handler.NewWalletHandler(walletSvc)
+	cartH := handler.NewCartHandler(cartSvc)
+	orderH := handler.NewOrderHandler(orderSvc)
+	keysH := handler.NewKeysHandler(keys)
+
+	authRequired := middleware.AuthRequired(middleware.AuthConfig{Keys: keys, Token: tokenOpts, Revocation: revocationSvc})
+	// для интеграций: JWT или X-API-Key
+	integrationAuth := middleware.AuthRequired(middleware.AuthConfig{Keys: keys, Token: tokenOpts, Revocation: revocationSvc, APIKeys: apiKeySvc})
 
 	// Routes
+	app.Get("/.well-known/jwks.json", keysH.JWKS)
+
 	api := app.Group("/api/v1")
 
 	auth := api.Group("/auth")
 	auth.Post("/register", authH.Register)
 	auth.Post("/login", authH.Login)
+	auth.Post("/login/mfa", authH.LoginMFA)
+	auth.Post("/refresh", authH.Refresh)
+	auth.Post("/logout", authH.Logout)
+	auth.Post("/verify", verifyH.Verify)
+	auth.Post("/verify/resend", authRequired, verifyH.Resend)
+	auth.Post("/password/forgot", passwordH.Forgot)
+	auth.Post("/password/reset", passwordH.Reset)
+	auth.Get("/oidc/:provider/login", oidcH.Login)
+	auth.Get("/oidc/:provider/callback", oidcH.Callback)
 
 	products := api.Group("/products")
 	products.Get("/", prodH.List)            // public
@@ -106,17 +366,73 @@ func main() {
 	products.Get("/:id/pictures", picH.List) // public
 	api.Get("/pictures/:id", picH.Download)  // public
 
-	// seller-only
-	secured := products.Use(middleware.AuthRequired(middleware.AuthConfig{JWTSecret: cfg.Auth.JWTSecret}))
-	secured.Use(middleware.RequireSeller())
-	secured.Post("/", prodH.Create)
+	sellers := api.Group("/sellers") // public storefronts
+	sellers.Get("/:slug", sellerH.Get)
+	sellers.Get("/:slug/products", sellerH.Products)
+
+	// продавцы — свои товары, модераторы и администраторы — любые
+	secured := products.Use(integrationAuth)
+	secured.Use(middleware.RequirePermission(domain.PermProductWrite, domain.PermProductWriteAny))
+	secured.Post("/", middleware.RequirePermission(domain.PermProductWrite), prodH.Create)
 	secured.Put("/:id", prodH.Update)
 	secured.Delete("/:id", prodH.Delete)
 
-	// pictures (seller only)
+	// pictures
 	secured.Post("/:id/pictures", picH.Upload)
 	secured.Delete("/:id/pictures/:pid", picH.Delete)
 	secured.Put("/:id/cover/:pid", picH.SetCover)
+
+	// current user (any role)
+	me := api.Group("/me", authRequired)
+	me.Get("/", userH.Get)
+	me.Patch("/", userH.Update)
+	me.Delete("/", userH.Delete)
+	me.Put("/avatar", userH.SetAvatar)
+	me.Delete("/avatar", userH.RemoveAvatar)
+	me.Put("/email", userH.ChangeEmail)
+	me.Get("/balance", walletH.Get)
+	me.Post("/balance/deposit", walletH.Deposit)
+	me.Put("/password", authH.ChangePassword)
+	me.Post("/mfa/totp", mfaH.Enroll)
+	me.Get("/mfa/totp/qr", mfaH.QRCode)
+	me.Post("/mfa/totp/confirm", mfaH.Confirm)
+	me.Delete("/mfa/totp", mfaH.Disable)
+	me.Post("/mfa/recovery-codes", mfaH.RegenerateRecoveryCodes)
+	me.Post("/api-keys", apiKeyH.Create)
+	me.Get("/api-keys", apiKeyH.List)
+	me.Delete("/api-keys/:id", apiKeyH.Revoke)
+
+	cart := api.Group("/cart", authRequired)
+	cart.Get("/", cartH.Get)
+	cart.Delete("/", cartH.Clear)
+	cart.Post("/items", cartH.AddItem)
+	cart.Put("/items/:id", cartH.UpdateItem)
+	cart.Delete("/items/:id", cartH.RemoveItem)
+
+	orders := api.Group("/orders", authRequired)
+	orders.Post("/", orderH.Checkout)
+	orders.Get("/", orderH.List)
+	orders.Get("/:id", orderH.Get)
+	orders.Get("/:id/history", orderH.History)
+
+	// seller fulfillment
+	sellerArea := api.Group("/seller", integrationAuth, middleware.RequirePermission(domain.PermOrderFulfill))
+	sellerArea.Get("/orders", orderH.ListForSeller)
+	sellerArea.Put("/orders/:id/status", orderH.UpdateStatus)
+	sellerArea.Get("/profile", sellerH.GetMine)
+	sellerArea.Put("/profile", sellerH.Save)
+	sellerArea.Put("/profile/logo", sellerH.SetLogo)
+	sellerArea.Delete("/profile/logo", sellerH.RemoveLogo)
+
+	// staff
+	admin := api.Group("/admin", authRequired)
+	admin.Get("/users", middleware.RequirePermission(domain.PermUserRead), adminH.ListUsers)
+	admin.Get("/users/:id", middleware.RequirePermission(domain.PermUserRead), adminH.GetUser)
+	admin.Post("/users/:id/suspend", middleware.RequirePermission(domain.PermUserBan), adminH.Suspend)
+	admin.Post("/users/:id/unsuspend", middleware.RequirePermission(domain.PermUserBan), adminH.Unsuspend)
+	admin.Put("/users/:id/role", middleware.RequirePermission(domain.PermUserRole), adminH.SetRole)
+	admin.Get("/audit", middleware.RequirePermission(domain.PermAuditRead), auditH.List)
+
 	// Graceful shutdown
 	go func() {
 		if err := app.Listen(cfg.Server.Addr); err != nil {
@@ -129,4 +445,17 @@ func main() {
 	<-stop
 	z.Infow("shutting down...")
 	_ = app.Shutdown()
+	stopRelay()
+	<-relayDone
+	stopJanitor()
+	<-janitorDone
+	jobs.Wait()
+	if producer != nil {
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelShutdown()
+		if err := producer.Shutdown(shutdownCtx); err != nil {
+			z.Warnw("kafka producer shutdown", "err", err)
+		}
+		z.Infow("kafka producer stopped", "stats", producer.Stats())
+	}
 }
@@ -1,26 +1,68 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"os/signal"
+	"syscall"
+
+	"github.com/segmentio/kafka-go"
+	"market/internal/config"
+	"market/internal/events"
+	"market/internal/logger"
 	"market/internal/message_broker"
-	"sync"
 )
 
-var address = []string{"localhost:9091", "localhost:9092", "localhost:9093"}
-
+// Воркер-пример: читает доменные события маркетплейса и логирует их.
 func main() {
-	wg := &sync.WaitGroup{}
-	wg.Go(func() {
-		producer, err := message_broker.NewProducer(address)
-		if err != nil {
-			log.Fatalf("Failed to create producer: %v", err)
-		}
-
-		defer producer.Close()
-		for i := 0; i < 10; i++ {
-			producer.ProduceAsync(fmt.Sprintf("Message %d", i), message_broker.TopicMy)
-		}
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config load: %v", err)
+	}
+	zl, err := logger.New(cfg.Logger.Level)
+	if err != nil {
+		log.Fatalf("logger: %v", err)
+	}
+	defer func() { _ = zl.Sync() }()
+	z := zl.Sugar()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	dlq, err := message_broker.NewProducer(message_broker.ProducerConfig{
+		Brokers:        cfg.Kafka.Brokers,
+		MaxAttempts:    cfg.Kafka.MaxAttempts,
+		InitialBackoff: cfg.Kafka.RetryBackoff,
+		MaxBackoff:     cfg.Kafka.MaxRetryBackoff,
+		Log:            z,
 	})
-	wg.Wait()
+	if err != nil {
+		z.Fatalw("kafka producer failed", "err", err)
+	}
+	defer dlq.Close()
+
+	consumer := message_broker.NewConsumer(message_broker.ConsumerConfig{
+		Brokers:        cfg.Kafka.Brokers,
+		GroupID:        cfg.Kafka.GroupID,
+		MaxAttempts:    cfg.Kafka.MaxAttempts,
+		InitialBackoff: cfg.Kafka.RetryBackoff,
+		MaxBackoff:     cfg.Kafka.MaxRetryBackoff,
+		DLQSuffix:      cfg.Kafka.DLQSuffix,
+	}, dlq, z)
+
+	logEvent := func(ctx context.Context, env events.Envelope, msg kafka.Message) error {
+		z.Infow("event",
+			"topic", msg.Topic, "offset", msg.Offset,
+			"id", env.ID, "type", env.Type, "version", env.Version,
+			"aggregate_id", env.AggregateID, "payload", string(env.Payload))
+		return nil
+	}
+	registry := events.NewRegistry(cfg.Kafka.Topics, cfg.Kafka.TopicPrefix)
+	for _, topic := range registry.Topics() {
+		message_broker.HandleJSON(consumer, topic, logEvent)
+	}
+
+	if err := consumer.Run(ctx); err != nil {
+		z.Fatalw("consumer stopped", "err", err)
+	}
 }
@@ -13,6 +13,8 @@ type Server struct {
 	Prefork      bool
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	// Заголовок с IP клиента за reverse proxy (например X-Forwarded-For); пусто — адрес соединения
+	ProxyHeader string
 }
 
 type DB struct {
@@ -23,9 +25,125 @@ type DB struct {
 	MaxConnIdleTime time.Duration
 }
 
+type SigningKey struct {
+	ID             string
+	Algorithm      string
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
 type Auth struct {
-	JWTSecret string
-	AccessTTL time.Duration
+	// Если Keys пуст, токены подписываются HS256 общим секретом JWTSecret
+	JWTSecret   string
+	Keys        []SigningKey
+	ActiveKeyID string
+	Issuer      string
+	Audience    string
+	Leeway      time.Duration
+	AccessTTL   time.Duration
+	RefreshTTL  time.Duration
+	// Кэш отозванных jti
+	RevocationCacheSize int
+	// Email пользователей, которые при старте получают роль admin (первый администратор)
+	BootstrapAdmins []string
+	// Насколько свежим должен быть вход пользователя без пароля для смены email и удаления
+	ReauthMaxAge time.Duration
+	// Период фоновой очистки просроченных записей (денылист токенов и т. п.)
+	CleanupInterval time.Duration
+	// Подтверждение email
+	EmailVerifyTTL            time.Duration
+	EmailVerifyResendInterval time.Duration
+	EmailVerifyURL            string
+	// Сброс пароля
+	PasswordResetTTL time.Duration
+	PasswordResetURL string
+	Login            Login
+	Password         Password
+	MFA              MFA
+	OIDC             OIDC
+}
+
+// OIDC — внешние провайдеры входа; ключ карты Providers — имя провайдера в URL
+type OIDC struct {
+	StateTTL  time.Duration
+	Providers map[string]OIDCProvider
+}
+
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// MFA — второй фактор (TOTP)
+type MFA struct {
+	Issuer string
+	// 32 байта в base64 для шифрования TOTP-секретов; пусто — ключ выводится из jwtSecret (только для разработки)
+	EncryptionKey string
+	RecoveryCodes int
+	TokenTTL      time.Duration
+}
+
+// Password — политика паролей и параметры хэширования
+type Password struct {
+	MinLength        int
+	MaxLength        int
+	BreachedListFile string
+	Algorithm        string // argon2id | bcrypt
+	BcryptCost       int
+	Argon2Memory     uint32 // KiB
+	Argon2Iterations uint32
+	Argon2Threads    uint8
+}
+
+// Login — ограничение попыток входа
+type Login struct {
+	EmailFreeAttempts int
+	EmailLockoutAfter int
+	IPFreeAttempts    int
+	IPLockoutAfter    int
+	Window            time.Duration
+	BaseDelay         time.Duration
+	MaxDelay          time.Duration
+	LockoutDuration   time.Duration
+}
+
+type Kafka struct {
+	Brokers        []string
+	OutboxInterval time.Duration
+	OutboxBatch    int
+
+	// Топики событий по агрегатам (user, product, picture, order);
+	// для ненастроенных используется TopicPrefix + агрегат
+	Topics      map[string]string
+	TopicPrefix string
+
+	// Producer
+	ProducerBuffer  int
+	ProducerWorkers int
+
+	// Consumer
+	GroupID string
+
+	// Политика повторов (producer и consumer)
+	MaxAttempts     int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	DLQSuffix       string
+}
+
+type Events struct {
+	Publisher string // outbox | kafka | memory
+}
+
+type Mailer struct {
+	Driver string // log | file
+	From   string
+	Dir    string // для file
+	// Письма отправляются в фоне после commit; Timeout ограничивает одну отправку
+	Timeout time.Duration
 }
 
 type Logger struct {
@@ -36,6 +154,9 @@ type Config struct {
 	Server Server
 	DB     DB
 	Auth   Auth
+	Kafka  Kafka
+	Events Events
+	Mailer Mailer
 	Logger Logger
 }
 
@@ -54,6 +175,50 @@ func Load() (*Config, error) {
 	v.SetDefault("server.prefork", true)
 	v.SetDefault("server.readTimeout", "5s")
 	v.SetDefault("server.writeTimeout", "10s")
+	v.SetDefault("auth.refreshTTL", "720h")
+	v.SetDefault("auth.issuer", "market")
+	v.SetDefault("auth.audience", "market-api")
+	v.SetDefault("auth.leeway", "30s")
+	v.SetDefault("auth.revocationCacheSize", 10000)
+	v.SetDefault("auth.cleanupInterval", "10m")
+	v.SetDefault("auth.reauthMaxAge", "5m")
+	v.SetDefault("auth.emailVerifyTTL", "48h")
+	v.SetDefault("auth.emailVerifyResendInterval", "1m")
+	v.SetDefault("auth.passwordResetTTL", "30m")
+	v.SetDefault("auth.login.emailFreeAttempts", 3)
+	v.SetDefault("auth.login.emailLockoutAfter", 10)
+	v.SetDefault("auth.login.ipFreeAttempts", 20)
+	v.SetDefault("auth.login.ipLockoutAfter", 100)
+	v.SetDefault("auth.login.window", "15m")
+	v.SetDefault("auth.login.baseDelay", "1s")
+	v.SetDefault("auth.login.maxDelay", "1m")
+	v.SetDefault("auth.login.lockoutDuration", "15m")
+	v.SetDefault("auth.password.minLength", 8)
+	v.SetDefault("auth.password.maxLength", 128)
+	v.SetDefault("auth.password.algorithm", "argon2id")
+	v.SetDefault("auth.password.bcryptCost", 12)
+	v.SetDefault("auth.password.argon2Memory", 65536)
+	v.SetDefault("auth.password.argon2Iterations", 3)
+	v.SetDefault("auth.password.argon2Threads", 2)
+	v.SetDefault("auth.mfa.issuer", "Market")
+	v.SetDefault("auth.mfa.recoveryCodes", 10)
+	v.SetDefault("auth.mfa.tokenTTL", "5m")
+	v.SetDefault("auth.oidc.stateTTL", "10m")
+	v.SetDefault("events.publisher", "outbox")
+	v.SetDefault("mailer.driver", "log")
+	v.SetDefault("mailer.from", "no-reply@market.local")
+	v.SetDefault("mailer.dir", "./mail")
+	v.SetDefault("mailer.timeout", "30s")
+	v.SetDefault("kafka.outboxInterval", "1s")
+	v.SetDefault("kafka.outboxBatch", 100)
+	v.SetDefault("kafka.topicPrefix", "market.")
+	v.SetDefault("kafka.producerBuffer", 100)
+	v.SetDefault("kafka.producerWorkers", 10)
+	v.SetDefault("kafka.groupId", "market")
+	v.SetDefault("kafka.maxAttempts", 5)
+	v.SetDefault("kafka.retryBackoff", "200ms")
+	v.SetDefault("kafka.maxRetryBackoff", "10s")
+	v.SetDefault("kafka.dlqSuffix", ".dlq")
 
 	// Файл опционален — при отсутствии используем ENV/дефолты
 	if err := v.ReadInConfig(); err != nil {
@@ -69,6 +234,7 @@ func Load() (*Config, error) {
 	// Durations и точные значения из ENV/файла
 	c.Server.ReadTimeout = v.GetDuration("server.readTimeout")
 	c.Server.WriteTimeout = v.GetDuration("server.writeTimeout")
+	c.Server.ProxyHeader = v.GetString("server.proxyHeader")
 
 	c.DB.DSN = v.GetString("db.dsn")
 	c.DB.MaxConns = int32(v.GetInt("db.maxConns"))
@@ -77,8 +243,82 @@ func Load() (*Config, error) {
 	c.DB.MaxConnIdleTime = v.GetDuration("db.maxConnIdleTime")
 
 	c.Auth.JWTSecret = v.GetString("auth.jwtSecret")
+	if err := v.UnmarshalKey("auth.keys", &c.Auth.Keys); err != nil {
+		return nil, err
+	}
+	c.Auth.ActiveKeyID = v.GetString("auth.activeKeyId")
+	c.Auth.Issuer = v.GetString("auth.issuer")
+	c.Auth.Audience = v.GetString("auth.audience")
+	c.Auth.Leeway = v.GetDuration("auth.leeway")
 	c.Auth.AccessTTL = v.GetDuration("auth.accessTTL")
+	c.Auth.RefreshTTL = v.GetDuration("auth.refreshTTL")
+	c.Auth.RevocationCacheSize = v.GetInt("auth.revocationCacheSize")
+	c.Auth.CleanupInterval = v.GetDuration("auth.cleanupInterval")
+	c.Auth.ReauthMaxAge = v.GetDuration("auth.reauthMaxAge")
+	c.Auth.BootstrapAdmins = splitList(v.GetStringSlice("auth.bootstrapAdmins"))
+	c.Auth.EmailVerifyTTL = v.GetDuration("auth.emailVerifyTTL")
+	c.Auth.EmailVerifyResendInterval = v.GetDuration("auth.emailVerifyResendInterval")
+	c.Auth.EmailVerifyURL = v.GetString("auth.emailVerifyURL")
+	c.Auth.PasswordResetTTL = v.GetDuration("auth.passwordResetTTL")
+	c.Auth.PasswordResetURL = v.GetString("auth.passwordResetURL")
+	c.Auth.Login.EmailFreeAttempts = v.GetInt("auth.login.emailFreeAttempts")
+	c.Auth.Login.EmailLockoutAfter = v.GetInt("auth.login.emailLockoutAfter")
+	c.Auth.Login.IPFreeAttempts = v.GetInt("auth.login.ipFreeAttempts")
+	c.Auth.Login.IPLockoutAfter = v.GetInt("auth.login.ipLockoutAfter")
+	c.Auth.Login.Window = v.GetDuration("auth.login.window")
+	c.Auth.Login.BaseDelay = v.GetDuration("auth.login.baseDelay")
+	c.Auth.Login.MaxDelay = v.GetDuration("auth.login.maxDelay")
+	c.Auth.Login.LockoutDuration = v.GetDuration("auth.login.lockoutDuration")
+	c.Auth.Password.MinLength = v.GetInt("auth.password.minLength")
+	c.Auth.Password.MaxLength = v.GetInt("auth.password.maxLength")
+	c.Auth.Password.BreachedListFile = v.GetString("auth.password.breachedListFile")
+	c.Auth.Password.Algorithm = v.GetString("auth.password.algorithm")
+	c.Auth.Password.BcryptCost = v.GetInt("auth.password.bcryptCost")
+	c.Auth.Password.Argon2Memory = v.GetUint32("auth.password.argon2Memory")
+	c.Auth.Password.Argon2Iterations = v.GetUint32("auth.password.argon2Iterations")
+	c.Auth.Password.Argon2Threads = uint8(v.GetUint("auth.password.argon2Threads"))
+	c.Auth.MFA.Issuer = v.GetString("auth.mfa.issuer")
+	c.Auth.MFA.EncryptionKey = v.GetString("auth.mfa.encryptionKey")
+	c.Auth.MFA.RecoveryCodes = v.GetInt("auth.mfa.recoveryCodes")
+	c.Auth.MFA.TokenTTL = v.GetDuration("auth.mfa.tokenTTL")
+	c.Auth.OIDC.StateTTL = v.GetDuration("auth.oidc.stateTTL")
+	if err := v.UnmarshalKey("auth.oidc.providers", &c.Auth.OIDC.Providers); err != nil {
+		return nil, err
+	}
+
+	c.Kafka.Brokers = splitList(v.GetStringSlice("kafka.brokers"))
+	c.Kafka.OutboxInterval = v.GetDuration("kafka.outboxInterval")
+	c.Kafka.OutboxBatch = v.GetInt("kafka.outboxBatch")
+	c.Kafka.Topics = v.GetStringMapString("kafka.topics")
+	c.Kafka.TopicPrefix = v.GetString("kafka.topicPrefix")
+	c.Kafka.ProducerBuffer = v.GetInt("kafka.producerBuffer")
+	c.Kafka.ProducerWorkers = v.GetInt("kafka.producerWorkers")
+	c.Kafka.GroupID = v.GetString("kafka.groupId")
+	c.Kafka.MaxAttempts = v.GetInt("kafka.maxAttempts")
+	c.Kafka.RetryBackoff = v.GetDuration("kafka.retryBackoff")
+	c.Kafka.MaxRetryBackoff = v.GetDuration("kafka.maxRetryBackoff")
+	c.Kafka.DLQSuffix = v.GetString("kafka.dlqSuffix")
+
+	c.Events.Publisher = v.GetString("events.publisher")
+
+	c.Mailer.Driver = v.GetString("mailer.driver")
+	c.Mailer.From = v.GetString("mailer.from")
+	c.Mailer.Dir = v.GetString("mailer.dir")
+	c.Mailer.Timeout = v.GetDuration("mailer.timeout")
 
 	c.Logger.Level = v.GetString("logger.level")
 	return c, nil
 }
+
+// splitList дополнительно режет элементы по запятой: из ENV список приходит одной строкой.
+func splitList(in []string) []string {
+	var out []string
+	for _, item := range in {
+		for _, part := range strings.Split(item, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package domain
+
+import "context"
+
+// RequestMetaKey — ключ *RequestMeta в контексте запроса. Middleware кладёт его
+// в user values fasthttp, поэтому он виден через c.Context() и производные контексты.
+type RequestMetaKey struct{}
+
+// RequestMeta — кто и откуда выполняет запрос; нужен журналу аудита.
+type RequestMeta struct {
+	ActorID   int64 // 0 — запрос без аутентификации
+	ActorRole Role
+	IP        string
+	RequestID string
+}
+
+// RequestMetaFrom возвращает данные запроса; вне HTTP-запроса — пустые.
+func RequestMetaFrom(ctx context.Context) RequestMeta {
+	if m, ok := ctx.Value(RequestMetaKey{}).(*RequestMeta); ok && m != nil {
+		return *m
+	}
+	return RequestMeta{}
+}
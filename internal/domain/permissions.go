@@ -0,0 +1,92 @@
+package domain
+
+// Permission — право на действие в формате ресурс:действие[:область]
+type Permission string
+
+const (
+	PermProductWrite    Permission = "product:write"     // свои товары и их картинки
+	PermProductWriteAny Permission = "product:write:any" // любые товары (модерация)
+	PermOrderFulfill    Permission = "order:fulfill"     // заказы своих покупателей
+	PermUserRead        Permission = "user:read"
+	PermUserBan         Permission = "user:ban"
+	PermUserRole        Permission = "user:role" // назначение ролей
+	PermAuditRead       Permission = "audit:read"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleBuyer:  {},
+	RoleSeller: {PermProductWrite, PermOrderFulfill},
+	RoleModerator: {
+		PermProductWriteAny,
+		PermUserRead,
+		PermUserBan,
+	},
+	RoleAdmin: {
+		PermProductWrite,
+		PermProductWriteAny,
+		PermOrderFulfill,
+		PermUserRead,
+		PermUserBan,
+		PermUserRole,
+		PermAuditRead,
+	},
+}
+
+// Valid сообщает, что роль известна.
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Staff — модераторы и администраторы.
+func (r Role) Staff() bool {
+	return r == RoleModerator || r == RoleAdmin
+}
+
+// Can сообщает, есть ли у роли право p. Неизвестные роли прав не имеют.
+func (r Role) Can(p Permission) bool {
+	for _, granted := range rolePermissions[r] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Permissions возвращает права роли.
+func (r Role) Permissions() []Permission {
+	return append([]Permission(nil), rolePermissions[r]...)
+}
+
+// Actor — кто выполняет действие: пользователь и его роль из токена
+type Actor struct {
+	UserID int64
+	Role   Role
+	// Scopes ограничивают права роли при входе по API-ключу; nil — без ограничений
+	Scopes []Permission
+}
+
+// Can сообщает, есть ли право p у роли и разрешено ли оно областями ключа.
+func (a Actor) Can(p Permission) bool {
+	if !a.Role.Can(p) {
+		return false
+	}
+	if a.Scopes == nil {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManage разрешает действие над ресурсом владельца ownerID:
+// своим — по праву own, чужим — по праву others.
+func (a Actor) CanManage(ownerID int64, own, others Permission) bool {
+	if ownerID == a.UserID && a.Can(own) {
+		return true
+	}
+	return a.Can(others)
+}
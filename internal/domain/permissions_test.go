@@ -0,0 +1,103 @@
+package domain
+
+import "testing"
+
+func TestRoleCan(t *testing.T) {
+	tests := []struct {
+		role Role
+		perm Permission
+		want bool
+	}{
+		{RoleBuyer, PermProductWrite, false},
+		{RoleBuyer, PermUserRead, false},
+		{RoleSeller, PermProductWrite, true},
+		{RoleSeller, PermOrderFulfill, true},
+		{RoleSeller, PermProductWriteAny, false},
+		{RoleModerator, PermProductWriteAny, true},
+		{RoleModerator, PermUserBan, true},
+		{RoleModerator, PermProductWrite, false}, // модератор не создаёт товары
+		{RoleModerator, PermUserRole, false},
+		{RoleModerator, PermAuditRead, false},
+		{RoleAdmin, PermUserRole, true},
+		{RoleAdmin, PermAuditRead, true},
+		{Role("root"), PermProductWrite, false}, // неизвестная роль прав не имеет
+		{RoleAdmin, Permission("product:delete"), false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Can(tt.perm); got != tt.want {
+			t.Errorf("%s.Can(%s) = %v, want %v", tt.role, tt.perm, got, tt.want)
+		}
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	for _, r := range []Role{RoleBuyer, RoleSeller, RoleModerator, RoleAdmin} {
+		if !r.Valid() {
+			t.Errorf("%s: Valid() = false", r)
+		}
+	}
+	if Role("root").Valid() {
+		t.Error("unknown role is valid")
+	}
+}
+
+func TestRolePermissionsCopy(t *testing.T) {
+	perms := RoleSeller.Permissions()
+	perms[0] = PermAuditRead
+	if RoleSeller.Can(PermAuditRead) {
+		t.Fatal("Permissions() exposes the role table")
+	}
+}
+
+func TestActorCanManage(t *testing.T) {
+	seller := Actor{UserID: 1, Role: RoleSeller}
+	moderator := Actor{UserID: 2, Role: RoleModerator}
+	tests := []struct {
+		name    string
+		actor   Actor
+		ownerID int64
+		want    bool
+	}{
+		{"продавец: свой товар", seller, 1, true},
+		{"продавец: чужой товар", seller, 3, false},
+		{"модератор: чужой товар", moderator, 3, true},
+		{"модератор: свой товар", moderator, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.actor.CanManage(tt.ownerID, PermProductWrite, PermProductWriteAny); got != tt.want {
+				t.Fatalf("CanManage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActorCanWithScopes(t *testing.T) {
+	tests := []struct {
+		name  string
+		actor Actor
+		perm  Permission
+		want  bool
+	}{
+		{"JWT: без ограничений", Actor{Role: RoleSeller}, PermOrderFulfill, true},
+		{"ключ: право в областях", Actor{Role: RoleSeller, Scopes: []Permission{PermOrderFulfill}}, PermOrderFulfill, true},
+		{"ключ: права нет в областях", Actor{Role: RoleSeller, Scopes: []Permission{PermOrderFulfill}}, PermProductWrite, false},
+		{"ключ: область шире роли", Actor{Role: RoleSeller, Scopes: []Permission{PermUserRole}}, PermUserRole, false},
+		{"ключ: пустые области", Actor{Role: RoleAdmin, Scopes: []Permission{}}, PermProductWrite, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.actor.Can(tt.perm); got != tt.want {
+				t.Fatalf("Can(%s) = %v, want %v", tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActorCanManageWithScopes(t *testing.T) {
+	// ключ продавца только для заказов не даёт менять даже свои товары
+	a := Actor{UserID: 1, Role: RoleSeller, Scopes: []Permission{PermOrderFulfill}}
+	if a.CanManage(1, PermProductWrite, PermProductWriteAny) {
+		t.Fatal("scope restriction ignored")
+	}
+}
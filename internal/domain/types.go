@@ -1,12 +1,17 @@
 package domain
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Role string
 
 const (
-	RoleBuyer  Role = "buyer"
-	RoleSeller Role = "seller"
+	RoleBuyer     Role = "buyer"
+	RoleSeller    Role = "seller"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
 )
 
 type User struct {
@@ -15,6 +20,18 @@ type User struct {
 	PasswordHash string    `json:"-"`
 	Role         Role      `json:"role"`
 	CreatedAt    time.Time `json:"created_at"`
+	// nil — адрес ещё не подтверждён
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	DisplayName     *string    `json:"display_name,omitempty"`
+	Phone           *string    `json:"phone,omitempty"`
+	AvatarPictureID *int64     `json:"avatar_picture_id,omitempty"`
+	// Новый адрес, который ещё не подтверждён
+	PendingEmail *string    `json:"pending_email,omitempty"`
+	DeletedAt    *time.Time `json:"-"`
+	// Блокировка персоналом: вход и публикация товаров запрещены
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspensionReason *string    `json:"suspension_reason,omitempty"`
+	SuspendedBy      *int64     `json:"suspended_by,omitempty"`
 }
 
 type Product struct {
@@ -36,3 +53,181 @@ type Picture struct {
 	CreatedAt time.Time `json:"created_at"`
 	Position  int       `json:"position,omitempty"` // позиция в рамках продукта
 }
+
+type Wallet struct {
+	ID           int64 `json:"id,omitempty"` // balance_users.id, 0 если кошелёк ещё не создан
+	UserID       int64 `json:"user_id"`
+	BalanceCents int64 `json:"balance_cents"`
+}
+
+type CartItem struct {
+	ProductID      int64  `json:"product_id"`
+	Name           string `json:"name"`
+	PriceCents     int64  `json:"price_cents"` // текущая цена товара
+	Quantity       int    `json:"quantity"`
+	Stock          int    `json:"stock"`
+	LineTotalCents int64  `json:"line_total_cents"`
+}
+
+type Cart struct {
+	UserID     int64      `json:"user_id"`
+	Items      []CartItem `json:"items"`
+	TotalCents int64      `json:"total_cents"`
+}
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+// OrderLine — позиция, которую покупатель хочет купить.
+type OrderLine struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+type OrderItem struct {
+	ProductID      *int64 `json:"product_id"` // NULL, если товар уже удалён
+	Name           string `json:"name"`
+	PriceCents     int64  `json:"price_cents"` // цена на момент покупки
+	Quantity       int    `json:"quantity"`
+	LineTotalCents int64  `json:"line_total_cents"`
+}
+
+type Order struct {
+	ID         int64       `json:"id"`
+	BuyerID    int64       `json:"buyer_id"`
+	SellerID   int64       `json:"seller_id"`
+	Status     OrderStatus `json:"status"`
+	TotalCents int64       `json:"total_cents"`
+	Items      []OrderItem `json:"items"`
+	CreatedAt  time.Time   `json:"created_at"`
+	UpdatedAt  time.Time   `json:"updated_at"`
+}
+
+type OrderStatusChange struct {
+	OrderID   int64       `json:"order_id"`
+	From      OrderStatus `json:"from_status,omitempty"`
+	To        OrderStatus `json:"to_status"`
+	ChangedBy int64       `json:"changed_by"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// OutboxMessage — событие, ожидающее отправки в брокер.
+type OutboxMessage struct {
+	ID           int64
+	EventID      string
+	EventType    string
+	EventVersion int
+	AggregateID  string
+	Payload      []byte // JSON-конверт events.Envelope
+	CreatedAt    time.Time
+	Attempts     int
+}
+
+type RefreshToken struct {
+	ID       int64
+	UserID   int64
+	FamilyID string // сессия: все ротации одного входа
+	// AuthenticatedAt — когда в этой сессии вводили учётные данные; nil у сессий до миграции 0021
+	AuthenticatedAt *time.Time
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	RevokedAt       *time.Time
+	ReplacedBy      *int64
+}
+
+type EmailVerificationToken struct {
+	ID        int64
+	UserID    int64
+	Email     string // адрес, на который отправлено письмо
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type PasswordResetToken struct {
+	ID        int64
+	UserID    int64
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+type LoginAttempt struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	BlockedUntil  *time.Time
+}
+
+type TOTP struct {
+	UserID          int64
+	SecretEncrypted []byte
+	EnabledAt       *time.Time // nil — подключение не подтверждено
+	LastUsedStep    int64
+	CreatedAt       time.Time
+}
+
+// Identity — учётная запись пользователя у внешнего OIDC-провайдера
+type Identity struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OIDCState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// SellerProfile — публичная витрина продавца
+type SellerProfile struct {
+	UserID        int64     `json:"seller_id"`
+	Slug          string    `json:"slug"`
+	ShopName      string    `json:"shop_name"`
+	Description   string    `json:"description,omitempty"`
+	LogoPictureID *int64    `json:"logo_picture_id,omitempty"`
+	ContactEmail  *string   `json:"contact_email,omitempty"`
+	ContactPhone  *string   `json:"contact_phone,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// APIKey — ключ интеграции. Сам ключ показывается один раз при создании.
+type APIKey struct {
+	ID         int64        `json:"id"`
+	UserID     int64        `json:"user_id"`
+	Name       string       `json:"name"`
+	Prefix     string       `json:"prefix"`
+	Scopes     []Permission `json:"scopes"`
+	ExpiresAt  *time.Time   `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time   `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+// AuditEntry — запись журнала аудита. Diff: {"поле": {"before": ..., "after": ...}}
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorID    *int64          `json:"actor_id,omitempty"`
+	ActorRole  Role            `json:"actor_role,omitempty"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   string          `json:"entity_id,omitempty"`
+	Diff       json.RawMessage `json:"diff,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+}
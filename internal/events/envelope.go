@@ -0,0 +1,54 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event — типизированное доменное событие. Тип и версия определяют схему payload,
+// AggregateID используется как ключ сообщения: события одного агрегата
+// попадают в одну партицию и читаются по порядку.
+type Event interface {
+	EventType() string
+	EventVersion() int
+	AggregateID() string
+}
+
+// Envelope — формат, в котором события лежат в outbox и уходят в Kafka.
+type Envelope struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Version     int             `json:"version"`
+	OccurredAt  time.Time       `json:"occurred_at"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// New упаковывает событие в конверт с новым id и текущим временем.
+func New(e Event) (Envelope, error) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{
+		ID:          uuid.NewString(),
+		Type:        e.EventType(),
+		Version:     e.EventVersion(),
+		OccurredAt:  time.Now().UTC(),
+		AggregateID: e.AggregateID(),
+		Payload:     payload,
+	}, nil
+}
+
+// Decode разбирает сообщение в конверт и payload типа T.
+func Decode[T Event](data []byte) (Envelope, T, error) {
+	var env Envelope
+	var v T
+	if err := json.Unmarshal(data, &env); err != nil {
+		return env, v, err
+	}
+	err := json.Unmarshal(env.Payload, &v)
+	return env, v, err
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// Subscriber получает события от MemoryBus синхронно, в горутине публикующего.
+type Subscriber func(ctx context.Context, env Envelope) error
+
+// MemoryBus — in-process шина событий для тестов и локальной разработки без брокера.
+// Подписчики вызываются до коммита транзакции, в которой событие опубликовано,
+// поэтому при откате они всё равно его увидят.
+type MemoryBus struct {
+	mu   sync.RWMutex
+	subs map[string][]Subscriber // "" — подписка на все типы
+}
+
+func NewMemoryBus() *MemoryBus {
+	return &MemoryBus{subs: map[string][]Subscriber{}}
+}
+
+// Subscribe подписывает fn на события типа eventType.
+func (b *MemoryBus) Subscribe(eventType string, fn Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[eventType] = append(b.subs[eventType], fn)
+}
+
+// SubscribeAll подписывает fn на все события.
+func (b *MemoryBus) SubscribeAll(fn Subscriber) {
+	b.Subscribe("", fn)
+}
+
+// Publish упаковывает события в конверты и отдаёт их подписчикам. Ошибки подписчиков
+// не прерывают рассылку и возвращаются вместе.
+func (b *MemoryBus) Publish(ctx context.Context, evts ...Event) error {
+	var errs []error
+	for _, e := range evts {
+		env, err := New(e)
+		if err != nil {
+			return err
+		}
+		b.mu.RLock()
+		subs := append(append([]Subscriber(nil), b.subs[env.Type]...), b.subs[""]...)
+		b.mu.RUnlock()
+		for _, fn := range subs {
+			if err := fn(ctx, env); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
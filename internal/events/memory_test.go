@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMemoryBusPublish(t *testing.T) {
+	tests := []struct {
+		name     string
+		subType  string // "" — SubscribeAll
+		publish  []Event
+		wantSeen []string
+	}{
+		{
+			name:     "подписка на тип",
+			subType:  TypeUserRegistered,
+			publish:  []Event{UserRegistered{UserID: 1}, UserDeleted{UserID: 1}},
+			wantSeen: []string{TypeUserRegistered},
+		},
+		{
+			name:     "подписка на все",
+			subType:  "",
+			publish:  []Event{UserRegistered{UserID: 1}, UserDeleted{UserID: 1}},
+			wantSeen: []string{TypeUserRegistered, TypeUserDeleted},
+		},
+		{
+			name:     "нет подходящих событий",
+			subType:  TypeOrderPlaced,
+			publish:  []Event{UserDeleted{UserID: 2}},
+			wantSeen: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := NewMemoryBus()
+			var seen []string
+			fn := func(_ context.Context, env Envelope) error {
+				seen = append(seen, env.Type)
+				return nil
+			}
+			if tt.subType == "" {
+				bus.SubscribeAll(fn)
+			} else {
+				bus.Subscribe(tt.subType, fn)
+			}
+			if err := bus.Publish(context.Background(), tt.publish...); err != nil {
+				t.Fatalf("Publish: %v", err)
+			}
+			if len(seen) != len(tt.wantSeen) {
+				t.Fatalf("seen %v, want %v", seen, tt.wantSeen)
+			}
+			for i := range seen {
+				if seen[i] != tt.wantSeen[i] {
+					t.Fatalf("seen %v, want %v", seen, tt.wantSeen)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryBusPayload(t *testing.T) {
+	bus := NewMemoryBus()
+	var got Envelope
+	bus.Subscribe(TypeUserRegistered, func(_ context.Context, env Envelope) error {
+		got = env
+		return nil
+	})
+	if err := bus.Publish(context.Background(), UserRegistered{UserID: 7, Email: "a@example.com"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got.AggregateID != "7" || got.Version != 1 || got.ID == "" {
+		t.Fatalf("unexpected envelope: %+v", got)
+	}
+}
+
+func TestMemoryBusSubscriberErrors(t *testing.T) {
+	errA, errB := errors.New("a"), errors.New("b")
+	bus := NewMemoryBus()
+	calls := 0
+	bus.Subscribe(TypeUserDeleted, func(context.Context, Envelope) error { calls++; return errA })
+	bus.SubscribeAll(func(context.Context, Envelope) error { calls++; return errB })
+	bus.SubscribeAll(func(context.Context, Envelope) error { calls++; return nil })
+
+	err := bus.Publish(context.Background(), UserDeleted{UserID: 1})
+	if calls != 3 {
+		t.Fatalf("calls = %d, want 3: ошибка подписчика не должна прерывать рассылку", calls)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("err = %v, want both subscriber errors", err)
+	}
+}
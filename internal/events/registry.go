@@ -0,0 +1,41 @@
+package events
+
+import "strings"
+
+// Registry сопоставляет типы событий топикам Kafka. Топик выбирается по агрегату
+// (часть типа до точки: "product.updated" -> "product"); для агрегатов без явной
+// настройки используется prefix + агрегат.
+type Registry struct {
+	topics map[string]string
+	prefix string
+}
+
+func NewRegistry(topics map[string]string, prefix string) *Registry {
+	t := make(map[string]string, len(topics))
+	for aggregate, topic := range topics {
+		t[strings.ToLower(aggregate)] = topic
+	}
+	return &Registry{topics: t, prefix: prefix}
+}
+
+func (r *Registry) Topic(eventType string) string {
+	aggregate, _, _ := strings.Cut(eventType, ".")
+	if topic, ok := r.topics[aggregate]; ok {
+		return topic
+	}
+	return r.prefix + aggregate
+}
+
+// Topics возвращает топики всех известных событий — удобно для подписки потребителя.
+func (r *Registry) Topics() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, t := range AllTypes {
+		topic := r.Topic(t)
+		if !seen[topic] {
+			seen[topic] = true
+			out = append(out, topic)
+		}
+	}
+	return out
+}
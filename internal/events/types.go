@@ -0,0 +1,168 @@
+package events
+
+import (
+	"strconv"
+
+	"market/internal/domain"
+)
+
+const (
+	TypeUserRegistered      = "user.registered"
+	TypeUserEmailVerified   = "user.email_verified"
+	TypeUserDeleted         = "user.deleted"
+	TypeUserSuspended       = "user.suspended"
+	TypeUserUnsuspended     = "user.unsuspended"
+	TypeUserRoleChanged     = "user.role_changed"
+	TypeProductCreated      = "product.created"
+	TypeProductUpdated      = "product.updated"
+	TypeProductDeleted      = "product.deleted"
+	TypeProductCoverChanged = "product.cover_changed"
+	TypePictureAttached     = "picture.attached"
+	TypePictureDetached     = "picture.detached"
+	TypeOrderPlaced         = "order.placed"
+	TypeOrderStatusChanged  = "order.status_changed"
+)
+
+// AllTypes — все известные типы событий.
+var AllTypes = []string{
+	TypeUserRegistered, TypeUserEmailVerified, TypeUserDeleted,
+	TypeUserSuspended, TypeUserUnsuspended, TypeUserRoleChanged,
+	TypeProductCreated, TypeProductUpdated, TypeProductDeleted, TypeProductCoverChanged,
+	TypePictureAttached, TypePictureDetached,
+	TypeOrderPlaced, TypeOrderStatusChanged,
+}
+
+func id(v int64) string { return strconv.FormatInt(v, 10) }
+
+type UserRegistered struct {
+	UserID int64       `json:"user_id"`
+	Email  string      `json:"email"`
+	Role   domain.Role `json:"role"`
+}
+
+func (UserRegistered) EventType() string     { return TypeUserRegistered }
+func (UserRegistered) EventVersion() int     { return 1 }
+func (e UserRegistered) AggregateID() string { return id(e.UserID) }
+
+type UserEmailVerified struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func (UserEmailVerified) EventType() string     { return TypeUserEmailVerified }
+func (UserEmailVerified) EventVersion() int     { return 1 }
+func (e UserEmailVerified) AggregateID() string { return id(e.UserID) }
+
+// UserDeleted: учётная запись удалена, персональные данные стёрты.
+type UserDeleted struct {
+	UserID int64 `json:"user_id"`
+}
+
+func (UserDeleted) EventType() string     { return TypeUserDeleted }
+func (UserDeleted) EventVersion() int     { return 1 }
+func (e UserDeleted) AggregateID() string { return id(e.UserID) }
+
+type UserSuspended struct {
+	UserID int64  `json:"user_id"`
+	Reason string `json:"reason"`
+	By     int64  `json:"by"`
+}
+
+func (UserSuspended) EventType() string     { return TypeUserSuspended }
+func (UserSuspended) EventVersion() int     { return 1 }
+func (e UserSuspended) AggregateID() string { return id(e.UserID) }
+
+type UserUnsuspended struct {
+	UserID int64 `json:"user_id"`
+	By     int64 `json:"by"`
+}
+
+func (UserUnsuspended) EventType() string     { return TypeUserUnsuspended }
+func (UserUnsuspended) EventVersion() int     { return 1 }
+func (e UserUnsuspended) AggregateID() string { return id(e.UserID) }
+
+type UserRoleChanged struct {
+	UserID       int64       `json:"user_id"`
+	Role         domain.Role `json:"role"`
+	PreviousRole domain.Role `json:"previous_role"`
+	By           int64       `json:"by"`
+}
+
+func (UserRoleChanged) EventType() string     { return TypeUserRoleChanged }
+func (UserRoleChanged) EventVersion() int     { return 1 }
+func (e UserRoleChanged) AggregateID() string { return id(e.UserID) }
+
+type ProductCreated struct {
+	Product domain.Product `json:"product"`
+}
+
+func (ProductCreated) EventType() string     { return TypeProductCreated }
+func (ProductCreated) EventVersion() int     { return 1 }
+func (e ProductCreated) AggregateID() string { return id(e.Product.ID) }
+
+type ProductUpdated struct {
+	Product domain.Product `json:"product"`
+}
+
+func (ProductUpdated) EventType() string     { return TypeProductUpdated }
+func (ProductUpdated) EventVersion() int     { return 1 }
+func (e ProductUpdated) AggregateID() string { return id(e.Product.ID) }
+
+type ProductDeleted struct {
+	ProductID int64 `json:"product_id"`
+	SellerID  int64 `json:"seller_id"`
+}
+
+func (ProductDeleted) EventType() string     { return TypeProductDeleted }
+func (ProductDeleted) EventVersion() int     { return 1 }
+func (e ProductDeleted) AggregateID() string { return id(e.ProductID) }
+
+type ProductCoverChanged struct {
+	ProductID int64 `json:"product_id"`
+	PictureID int64 `json:"picture_id"`
+}
+
+func (ProductCoverChanged) EventType() string     { return TypeProductCoverChanged }
+func (ProductCoverChanged) EventVersion() int     { return 1 }
+func (e ProductCoverChanged) AggregateID() string { return id(e.ProductID) }
+
+// События картинок ключуются по товару, чтобы идти по порядку с событиями товара.
+type PictureAttached struct {
+	ProductID int64  `json:"product_id"`
+	PictureID int64  `json:"picture_id"`
+	MIMEType  string `json:"mime_type"`
+	Position  int    `json:"position"`
+}
+
+func (PictureAttached) EventType() string     { return TypePictureAttached }
+func (PictureAttached) EventVersion() int     { return 1 }
+func (e PictureAttached) AggregateID() string { return id(e.ProductID) }
+
+type PictureDetached struct {
+	ProductID int64 `json:"product_id"`
+	PictureID int64 `json:"picture_id"`
+	Deleted   bool  `json:"deleted"` // картинка удалена из хранилища
+}
+
+func (PictureDetached) EventType() string     { return TypePictureDetached }
+func (PictureDetached) EventVersion() int     { return 1 }
+func (e PictureDetached) AggregateID() string { return id(e.ProductID) }
+
+type OrderPlaced struct {
+	Order domain.Order `json:"order"`
+}
+
+func (OrderPlaced) EventType() string     { return TypeOrderPlaced }
+func (OrderPlaced) EventVersion() int     { return 1 }
+func (e OrderPlaced) AggregateID() string { return id(e.Order.ID) }
+
+type OrderStatusChanged struct {
+	OrderID   int64              `json:"order_id"`
+	From      domain.OrderStatus `json:"from_status"`
+	To        domain.OrderStatus `json:"to_status"`
+	ChangedBy int64              `json:"changed_by"`
+}
+
+func (OrderStatusChanged) EventType() string     { return TypeOrderStatusChanged }
+func (OrderStatusChanged) EventVersion() int     { return 1 }
+func (e OrderStatusChanged) AggregateID() string { return id(e.OrderID) }
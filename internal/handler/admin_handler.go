@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/domain"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type AdminHandler struct {
+	svc *service.AdminService
+}
+
+func NewAdminHandler(svc *service.AdminService) *AdminHandler {
+	return &AdminHandler{svc: svc}
+}
+
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrSuspensionReasonRequired), errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrSelfAction):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrStaffTarget):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return err
+}
+
+// GET /api/v1/admin/users?q=&role=&suspended=&limit=&offset=
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit", "50"), 10, 32)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 32)
+	f := repository.UserFilter{
+		Limit:  int32(limit),
+		Offset: int32(offset),
+		Query:  c.Query("q"),
+		Role:   domain.Role(c.Query("role")),
+	}
+	if v := c.Query("suspended"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid suspended flag")
+		}
+		f.Suspended = &b
+	}
+	users, err := h.svc.ListUsers(c.Context(), f)
+	if err != nil {
+		return err
+	}
+	return c.JSON(users)
+}
+
+// GET /api/v1/admin/users/:id
+func (h *AdminHandler) GetUser(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	u, err := h.svc.GetUser(c.Context(), id)
+	if err != nil {
+		return adminError(err)
+	}
+	return c.JSON(u)
+}
+
+type suspendReq struct {
+	Reason string `json:"reason"`
+}
+
+// POST /api/v1/admin/users/:id/suspend
+func (h *AdminHandler) Suspend(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var req suspendReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	if err := h.svc.Suspend(c.Context(), middleware.ActorFrom(c), id, req.Reason); err != nil {
+		return adminError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/admin/users/:id/unsuspend
+func (h *AdminHandler) Unsuspend(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	if err := h.svc.Unsuspend(c.Context(), middleware.ActorFrom(c), id); err != nil {
+		return adminError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type setRoleReq struct {
+	Role domain.Role `json:"role"`
+}
+
+// PUT /api/v1/admin/users/:id/role
+func (h *AdminHandler) SetRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var req setRoleReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	if err := h.svc.SetRole(c.Context(), middleware.ActorFrom(c), id, req.Role); err != nil {
+		return adminError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type APIKeyHandler struct {
+	svc *service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+// POST /api/v1/me/api-keys
+func (h *APIKeyHandler) Create(c *fiber.Ctx) error {
+	var req service.APIKeyCreateInput
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	k, err := h.svc.Create(c.Context(), middleware.ActorFrom(c), req)
+	if err != nil {
+		if errors.Is(err, service.ErrAPIKeyLimit) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(k)
+}
+
+// GET /api/v1/me/api-keys
+func (h *APIKeyHandler) List(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	keys, err := h.svc.List(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(keys)
+}
+
+// DELETE /api/v1/me/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.Revoke(c.Context(), userID, id); err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type AuditHandler struct {
+	audit *service.Auditor
+}
+
+func NewAuditHandler(audit *service.Auditor) *AuditHandler {
+	return &AuditHandler{audit: audit}
+}
+
+// GET /api/v1/admin/audit?actor_id=&entity_type=&entity_id=&action=&from=&to=&limit=&offset=
+// from и to — RFC 3339, to не включительно.
+func (h *AuditHandler) List(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit", "50"), 10, 32)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 32)
+	f := repository.AuditFilter{
+		Limit:      int32(limit),
+		Offset:     int32(offset),
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		Action:     c.Query("action"),
+	}
+	if f.EntityID != "" && f.EntityType == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "entity_id requires entity_type")
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid actor_id")
+		}
+		f.ActorID = id
+	}
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid from")
+		}
+		f.From = &t
+	}
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid to")
+		}
+		f.To = &t
+	}
+	entries, err := h.audit.List(c.Context(), f)
+	if err != nil {
+		return err
+	}
+	return c.JSON(entries)
+}
@@ -1,8 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
 	"market/internal/domain"
+	"market/internal/middleware"
+	"market/internal/repository"
 	"market/internal/service"
 )
 
@@ -34,6 +41,22 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(res)
 }
 
+// loginError: ограничение попыток — 429 с Retry-After, неверные данные — 401.
+func loginError(c *fiber.Ctx, err error) error {
+	var throttled *service.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials), errors.Is(err, service.ErrInvalidMFAToken),
+		errors.Is(err, service.ErrInvalidMFACode), errors.Is(err, service.ErrMFANotEnabled):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrAccountSuspended):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return err
+}
+
 type loginReq struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
@@ -44,9 +67,90 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
 	}
-	res, err := h.svc.Login(c.Context(), req.Email, req.Password)
+	res, err := h.svc.Login(c.Context(), req.Email, req.Password, c.IP())
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return loginError(c, err)
+	}
+	return c.JSON(res)
+}
+
+type loginMFAReq struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// POST /api/v1/auth/login/mfa
+func (h *AuthHandler) LoginMFA(c *fiber.Ctx) error {
+	var req loginMFAReq
+	if err := c.BodyParser(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "mfa_token and code required")
+	}
+	res, err := h.svc.LoginMFA(c.Context(), req.MFAToken, req.Code, c.IP())
+	if err != nil {
+		return loginError(c, err)
 	}
 	return c.JSON(res)
 }
+
+type refreshReq struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(c *fiber.Ctx) error {
+	var req refreshReq
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "refresh_token required")
+	}
+	res, err := h.svc.Refresh(c.Context(), req.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidRefreshToken), errors.Is(err, service.ErrRefreshTokenReuse):
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		case errors.Is(err, service.ErrAccountSuspended):
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		return err
+	}
+	return c.JSON(res)
+}
+
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+	var req refreshReq
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "refresh_token required")
+	}
+	access := strings.TrimPrefix(c.Get("Authorization"), "Bearer ")
+	if err := h.svc.Logout(c.Context(), req.RefreshToken, access); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// PUT /api/v1/me/password
+func (h *AuthHandler) ChangePassword(c *fiber.Ctx) error {
+	var req changePasswordReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.ChangePassword(c.Context(), userID, req.CurrentPassword, req.NewPassword, c.IP()); err != nil {
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.As(err, &throttled):
+			return loginError(c, err)
+		case errors.Is(err, service.ErrWrongPassword):
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		case errors.Is(err, repository.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type CartHandler struct {
+	svc *service.CartService
+}
+
+func NewCartHandler(svc *service.CartService) *CartHandler {
+	return &CartHandler{svc: svc}
+}
+
+type cartItemReq struct {
+	ProductID int64 `json:"product_id"`
+	Quantity  int   `json:"quantity"`
+}
+
+// GET /api/v1/cart
+func (h *CartHandler) Get(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	cart, err := h.svc.Get(c.Context(), userID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(cart)
+}
+
+// POST /api/v1/cart/items
+func (h *CartHandler) AddItem(c *fiber.Ctx) error {
+	var req cartItemReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	cart, err := h.svc.AddItem(c.Context(), userID, req.ProductID, req.Quantity)
+	if err != nil {
+		return cartError(err)
+	}
+	return c.JSON(cart)
+}
+
+// PUT /api/v1/cart/items/:id
+func (h *CartHandler) UpdateItem(c *fiber.Ctx) error {
+	productID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid product id")
+	}
+	var req cartItemReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	cart, err := h.svc.UpdateItem(c.Context(), userID, productID, req.Quantity)
+	if err != nil {
+		return cartError(err)
+	}
+	return c.JSON(cart)
+}
+
+// DELETE /api/v1/cart/items/:id
+func (h *CartHandler) RemoveItem(c *fiber.Ctx) error {
+	productID, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid product id")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	cart, err := h.svc.RemoveItem(c.Context(), userID, productID)
+	if err != nil {
+		return cartError(err)
+	}
+	return c.JSON(cart)
+}
+
+// DELETE /api/v1/cart
+func (h *CartHandler) Clear(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.Clear(c.Context(), userID); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func cartError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrProductNotFound), errors.Is(err, repository.ErrCartItemNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrInsufficientStock):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+}
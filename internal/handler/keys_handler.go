@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"market/internal/utils"
+)
+
+type KeysHandler struct {
+	keys *utils.KeySet
+}
+
+func NewKeysHandler(keys *utils.KeySet) *KeysHandler {
+	return &KeysHandler{keys: keys}
+}
+
+// GET /.well-known/jwks.json (public)
+func (h *KeysHandler) JWKS(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.JSON(h.keys.JWKS())
+}
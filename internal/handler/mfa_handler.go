@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/middleware"
+	"market/internal/service"
+)
+
+type MFAHandler struct {
+	svc *service.MFAService
+}
+
+func NewMFAHandler(svc *service.MFAService) *MFAHandler {
+	return &MFAHandler{svc: svc}
+}
+
+// mfaError: неверный код или пароль — 403, ограничение попыток — 429 с Retry-After (см. loginError).
+func mfaError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrMFAAlreadyEnabled):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrMFANotEnabled), errors.Is(err, service.ErrMFANotEnrolled):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidMFACode), errors.Is(err, service.ErrWrongPassword):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	var throttled *service.LoginThrottledError
+	if errors.As(err, &throttled) {
+		return loginError(c, err)
+	}
+	return err
+}
+
+type mfaCodeReq struct {
+	Code string `json:"code"`
+}
+
+// POST /api/v1/me/mfa/totp
+func (h *MFAHandler) Enroll(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	res, err := h.svc.Enroll(c.Context(), userID)
+	if err != nil {
+		return mfaError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(res)
+}
+
+// GET /api/v1/me/mfa/totp/qr
+func (h *MFAHandler) QRCode(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	png, err := h.svc.QRCode(c.Context(), userID)
+	if err != nil {
+		return mfaError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Send(png)
+}
+
+// POST /api/v1/me/mfa/totp/confirm
+func (h *MFAHandler) Confirm(c *fiber.Ctx) error {
+	var req mfaCodeReq
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code required")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	codes, err := h.svc.Confirm(c.Context(), userID, req.Code)
+	if err != nil {
+		return mfaError(c, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+// POST /api/v1/me/mfa/recovery-codes
+func (h *MFAHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req mfaCodeReq
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code required")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	codes, err := h.svc.RegenerateRecoveryCodes(c.Context(), userID, req.Code, c.IP())
+	if err != nil {
+		return mfaError(c, err)
+	}
+	return c.JSON(fiber.Map{"recovery_codes": codes})
+}
+
+type mfaDisableReq struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// DELETE /api/v1/me/mfa/totp
+func (h *MFAHandler) Disable(c *fiber.Ctx) error {
+	var req mfaDisableReq
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code required")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.Disable(c.Context(), userID, req.Password, req.Code, c.IP()); err != nil {
+		return mfaError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
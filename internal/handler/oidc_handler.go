@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/service"
+)
+
+type OIDCHandler struct {
+	svc *service.OIDCService
+}
+
+func NewOIDCHandler(svc *service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{svc: svc}
+}
+
+func oidcError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUnknownOIDCProvider):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrInvalidOIDCState), errors.Is(err, service.ErrOIDCEmailNotVerified):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrOIDCLoginFailed):
+		return fiber.NewError(fiber.StatusUnauthorized, service.ErrOIDCLoginFailed.Error())
+	case errors.Is(err, service.ErrOIDCAccountConflict):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrAccountSuspended):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+	return err
+}
+
+// oidcStateCookie привязывает незавершённый вход к браузеру, который его начал.
+// SameSite=Lax: cookie уходит при переходе обратно с сайта провайдера.
+const oidcStateCookie = "oidc_state"
+
+func setOIDCStateCookie(c *fiber.Ctx, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/api/v1/auth/oidc/" + c.Params("provider"),
+		Expires:  expires,
+		Secure:   c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// GET /api/v1/auth/oidc/:provider/login
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	start, err := h.svc.AuthURL(c.Context(), c.Params("provider"))
+	if err != nil {
+		return oidcError(err)
+	}
+	setOIDCStateCookie(c, start.State, start.ExpiresAt)
+	return c.Redirect(start.URL, fiber.StatusFound)
+}
+
+// GET /api/v1/auth/oidc/:provider/callback?code=&state=
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	if e := c.Query("error"); e != "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "oidc login failed: "+e)
+	}
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "code and state required")
+	}
+	browserState := c.Cookies(oidcStateCookie)
+	// state одноразовый, cookie больше не нужна при любом исходе
+	setOIDCStateCookie(c, "", time.Unix(0, 0))
+	res, err := h.svc.Callback(c.Context(), c.Params("provider"), state, browserState, code)
+	if err != nil {
+		return oidcError(err)
+	}
+	return c.JSON(res)
+}
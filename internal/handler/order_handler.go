@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/domain"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type OrderHandler struct {
+	svc *service.OrderService
+}
+
+func NewOrderHandler(svc *service.OrderService) *OrderHandler {
+	return &OrderHandler{svc: svc}
+}
+
+type checkoutReq struct {
+	Items []domain.OrderLine `json:"items"` // пусто — оформить корзину
+}
+
+// POST /api/v1/orders
+func (h *OrderHandler) Checkout(c *fiber.Ctx) error {
+	var req checkoutReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+		}
+	}
+	buyerID := c.Locals(middleware.CtxUserID).(int64)
+	orders, err := h.svc.Checkout(c.Context(), buyerID, req.Items)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInsufficientStock), errors.Is(err, repository.ErrInsufficientFunds):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		case errors.Is(err, repository.ErrProductNotFound):
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	return c.Status(fiber.StatusCreated).JSON(orders)
+}
+
+// GET /api/v1/orders
+func (h *OrderHandler) List(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit", "50"), 10, 32)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 32)
+	buyerID := c.Locals(middleware.CtxUserID).(int64)
+	orders, err := h.svc.List(c.Context(), buyerID, int32(limit), int32(offset))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(orders)
+}
+
+// GET /api/v1/orders/:id
+func (h *OrderHandler) Get(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	buyerID := c.Locals(middleware.CtxUserID).(int64)
+	o, err := h.svc.Get(c.Context(), buyerID, id)
+	if err != nil {
+		if err.Error() == "forbidden: not owner" {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return c.JSON(o)
+}
+
+// GET /api/v1/orders/:id/history
+func (h *OrderHandler) History(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	items, err := h.svc.History(c.Context(), userID, id)
+	if err != nil {
+		if err.Error() == "forbidden: not owner" {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return c.JSON(items)
+}
+
+// GET /api/v1/seller/orders?status=&limit=&offset=
+func (h *OrderHandler) ListForSeller(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit", "50"), 10, 32)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 32)
+	status := domain.OrderStatus(c.Query("status", ""))
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	orders, err := h.svc.ListForSeller(c.Context(), sellerID, status, int32(limit), int32(offset))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(orders)
+}
+
+type orderStatusReq struct {
+	Status domain.OrderStatus `json:"status"`
+}
+
+// PUT /api/v1/seller/orders/:id/status
+func (h *OrderHandler) UpdateStatus(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
+	}
+	var req orderStatusReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	o, err := h.svc.UpdateStatus(c.Context(), sellerID, id, req.Status)
+	if err != nil {
+		switch {
+		case err.Error() == "forbidden: not owner":
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		case errors.Is(err, repository.ErrOrderStatusConflict), errors.Is(err, repository.ErrInsufficientFunds):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		default:
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+	return c.JSON(o)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/service"
+	"market/internal/utils"
+)
+
+type PasswordResetHandler struct {
+	svc *service.PasswordResetService
+}
+
+func NewPasswordResetHandler(svc *service.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{svc: svc}
+}
+
+type forgotPasswordReq struct {
+	Email string `json:"email"`
+}
+
+// POST /api/v1/auth/password/forgot
+// Ответ всегда 202, есть такой пользователь или нет.
+func (h *PasswordResetHandler) Forgot(c *fiber.Ctx) error {
+	var req forgotPasswordReq
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "email required")
+	}
+	if err := h.svc.Forgot(c.Context(), req.Email); err != nil {
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+type resetPasswordReq struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// POST /api/v1/auth/password/reset
+func (h *PasswordResetHandler) Reset(c *fiber.Ctx) error {
+	var req resetPasswordReq
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "token required")
+	}
+	if err := h.svc.Reset(c.Context(), req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) || errors.Is(err, service.ErrPasswordRequired) ||
+			errors.Is(err, utils.ErrWeakPassword) {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -37,8 +37,7 @@ func (h *PictureHandler) Upload(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "cannot read file")
 	}
 	mime := file.Header.Get("Content-Type")
-	sellerID := c.Locals(middleware.CtxUserID).(int64)
-	pic, err := h.svc.UploadAndAttach(c.Context(), sellerID, id, data, mime)
+	pic, err := h.svc.UploadAndAttach(c.Context(), middleware.ActorFrom(c), id, data, mime)
 	if err != nil {
 		if err.Error() == "forbidden: not owner" {
 			return fiber.NewError(fiber.StatusForbidden, err.Error())
@@ -86,8 +85,7 @@ func (h *PictureHandler) Delete(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid picture id")
 	}
 	hard := c.Query("hard") == "1"
-	sellerID := c.Locals(middleware.CtxUserID).(int64)
-	if err := h.svc.Detach(c.Context(), sellerID, productID, pictureID, hard); err != nil {
+	if err := h.svc.Detach(c.Context(), middleware.ActorFrom(c), productID, pictureID, hard); err != nil {
 		if err.Error() == "forbidden: not owner" {
 			return fiber.NewError(fiber.StatusForbidden, err.Error())
 		}
@@ -106,8 +104,7 @@ func (h *PictureHandler) SetCover(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid picture id")
 	}
-	sellerID := c.Locals(middleware.CtxUserID).(int64)
-	if err := h.svc.SetCover(c.Context(), sellerID, productID, pictureID); err != nil {
+	if err := h.svc.SetCover(c.Context(), middleware.ActorFrom(c), productID, pictureID); err != nil {
 		if err.Error() == "forbidden: not owner" {
 			return fiber.NewError(fiber.StatusForbidden, err.Error())
 		}
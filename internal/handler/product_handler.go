@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -24,6 +25,9 @@ func (h *ProductHandler) Create(c *fiber.Ctx) error {
 	sellerID := c.Locals(middleware.CtxUserID).(int64)
 	p, err := h.svc.Create(c.Context(), sellerID, req)
 	if err != nil {
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 	return c.Status(fiber.StatusCreated).JSON(p)
@@ -38,8 +42,7 @@ func (h *ProductHandler) Update(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
 	}
-	sellerID := c.Locals(middleware.CtxUserID).(int64)
-	p, err := h.svc.Update(c.Context(), sellerID, id, req)
+	p, err := h.svc.Update(c.Context(), middleware.ActorFrom(c), id, req)
 	if err != nil {
 		if err.Error() == "forbidden: not owner" {
 			return fiber.NewError(fiber.StatusForbidden, err.Error())
@@ -54,8 +57,7 @@ func (h *ProductHandler) Delete(c *fiber.Ctx) error {
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, "invalid id")
 	}
-	sellerID := c.Locals(middleware.CtxUserID).(int64)
-	if err := h.svc.Delete(c.Context(), sellerID, id); err != nil {
+	if err := h.svc.Delete(c.Context(), middleware.ActorFrom(c), id); err != nil {
 		if err.Error() == "forbidden: not owner" {
 			return fiber.NewError(fiber.StatusForbidden, err.Error())
 		}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/mailer"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type SellerHandler struct {
+	svc *service.SellerService
+}
+
+func NewSellerHandler(svc *service.SellerService) *SellerHandler {
+	return &SellerHandler{svc: svc}
+}
+
+func sellerError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrSellerProfileNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, repository.ErrSlugTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrEmailNotVerified):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrInvalidSlug), errors.Is(err, service.ErrInvalidShopName),
+		errors.Is(err, service.ErrDescriptionTooLong), errors.Is(err, service.ErrInvalidPhone),
+		errors.Is(err, mailer.ErrInvalidAddress):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+// GET /api/v1/sellers/:slug (public)
+func (h *SellerHandler) Get(c *fiber.Ctx) error {
+	p, err := h.svc.GetBySlug(c.Context(), c.Params("slug"))
+	if err != nil {
+		return sellerError(err)
+	}
+	return c.JSON(p)
+}
+
+// GET /api/v1/sellers/:slug/products?q=&limit=&offset= (public)
+func (h *SellerHandler) Products(c *fiber.Ctx) error {
+	limit, _ := strconv.ParseInt(c.Query("limit", "50"), 10, 32)
+	offset, _ := strconv.ParseInt(c.Query("offset", "0"), 10, 32)
+	items, err := h.svc.ListProducts(c.Context(), c.Params("slug"), int32(limit), int32(offset), c.Query("q", ""))
+	if err != nil {
+		return sellerError(err)
+	}
+	return c.JSON(items)
+}
+
+// GET /api/v1/seller/profile
+func (h *SellerHandler) GetMine(c *fiber.Ctx) error {
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	p, err := h.svc.GetMine(c.Context(), sellerID)
+	if err != nil {
+		return sellerError(err)
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/v1/seller/profile
+func (h *SellerHandler) Save(c *fiber.Ctx) error {
+	var req service.SellerProfileInput
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	p, err := h.svc.SaveProfile(c.Context(), sellerID, req)
+	if err != nil {
+		return sellerError(err)
+	}
+	return c.JSON(p)
+}
+
+// PUT /api/v1/seller/profile/logo (multipart form-data: file)
+func (h *SellerHandler) SetLogo(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing file")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot open file")
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot read file")
+	}
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	pic, err := h.svc.SetLogo(c.Context(), sellerID, data, file.Header.Get("Content-Type"))
+	if err != nil {
+		if errors.Is(err, repository.ErrSellerProfileNotFound) {
+			return sellerError(err)
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(pic)
+}
+
+// DELETE /api/v1/seller/profile/logo
+func (h *SellerHandler) RemoveLogo(c *fiber.Ctx) error {
+	sellerID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.RemoveLogo(c.Context(), sellerID); err != nil {
+		return sellerError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
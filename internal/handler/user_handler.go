@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/mailer"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type UserHandler struct {
+	svc *service.UserService
+}
+
+func NewUserHandler(svc *service.UserService) *UserHandler {
+	return &UserHandler{svc: svc}
+}
+
+func userError(c *fiber.Ctx, err error) error {
+	var throttled *service.LoginThrottledError
+	switch {
+	case errors.As(err, &throttled):
+		return loginError(c, err)
+	case errors.Is(err, repository.ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrWrongPassword), errors.Is(err, service.ErrReauthRequired):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, repository.ErrEmailTaken):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidDisplayName), errors.Is(err, service.ErrInvalidPhone),
+		errors.Is(err, service.ErrEmailUnchanged), errors.Is(err, mailer.ErrInvalidAddress):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return err
+}
+
+// GET /api/v1/me
+func (h *UserHandler) Get(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	u, err := h.svc.Get(c.Context(), userID)
+	if err != nil {
+		return userError(c, err)
+	}
+	return c.JSON(u)
+}
+
+// PATCH /api/v1/me
+func (h *UserHandler) Update(c *fiber.Ctx) error {
+	var req service.ProfileUpdateInput
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	u, err := h.svc.UpdateProfile(c.Context(), userID, req)
+	if err != nil {
+		return userError(c, err)
+	}
+	return c.JSON(u)
+}
+
+// PUT /api/v1/me/avatar (multipart form-data: file)
+func (h *UserHandler) SetAvatar(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "missing file")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot open file")
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "cannot read file")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	pic, err := h.svc.SetAvatar(c.Context(), userID, data, file.Header.Get("Content-Type"))
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return userError(c, err)
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(pic)
+}
+
+// DELETE /api/v1/me/avatar
+func (h *UserHandler) RemoveAvatar(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.RemoveAvatar(c.Context(), userID); err != nil {
+		return userError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// confirmation собирает подтверждение действия: пароль из тела, время входа из токена и IP.
+func confirmation(c *fiber.Ctx, password string) service.Confirmation {
+	authTime, _ := c.Locals(middleware.CtxAuthTime).(time.Time)
+	return service.Confirmation{Password: password, AuthTime: authTime, IP: c.IP()}
+}
+
+type changeEmailReq struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// PUT /api/v1/me/email
+func (h *UserHandler) ChangeEmail(c *fiber.Ctx) error {
+	var req changeEmailReq
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "email required")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.ChangeEmail(c.Context(), userID, req.Email, confirmation(c, req.Password)); err != nil {
+		return userError(c, err)
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+type deleteAccountReq struct {
+	Password string `json:"password"`
+}
+
+// DELETE /api/v1/me
+func (h *UserHandler) Delete(c *fiber.Ctx) error {
+	var req deleteAccountReq
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+		}
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.Delete(c.Context(), userID, confirmation(c, req.Password)); err != nil {
+		return userError(c, err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
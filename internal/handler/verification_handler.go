@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type VerificationHandler struct {
+	svc *service.EmailVerificationService
+}
+
+func NewVerificationHandler(svc *service.EmailVerificationService) *VerificationHandler {
+	return &VerificationHandler{svc: svc}
+}
+
+type verifyReq struct {
+	Token string `json:"token"`
+}
+
+// POST /api/v1/auth/verify
+func (h *VerificationHandler) Verify(c *fiber.Ctx) error {
+	var req verifyReq
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "token required")
+	}
+	if err := h.svc.Verify(c.Context(), req.Token); err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidVerificationToken):
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, repository.ErrEmailTaken):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return err
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/auth/verify/resend
+func (h *VerificationHandler) Resend(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	if err := h.svc.Resend(c.Context(), userID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		case errors.Is(err, service.ErrVerificationThrottled):
+			return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return err
+	}
+	return c.SendStatus(fiber.StatusAccepted)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"market/internal/middleware"
+	"market/internal/repository"
+	"market/internal/service"
+)
+
+type WalletHandler struct {
+	svc *service.WalletService
+}
+
+func NewWalletHandler(svc *service.WalletService) *WalletHandler {
+	return &WalletHandler{svc: svc}
+}
+
+// GET /api/v1/me/balance
+func (h *WalletHandler) Get(c *fiber.Ctx) error {
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	w, err := h.svc.Balance(c.Context(), userID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return c.JSON(w)
+}
+
+type depositReq struct {
+	AmountCents int64 `json:"amount_cents"`
+}
+
+// POST /api/v1/me/balance/deposit
+func (h *WalletHandler) Deposit(c *fiber.Ctx) error {
+	var req depositReq
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid json")
+	}
+	userID := c.Locals(middleware.CtxUserID).(int64)
+	w, err := h.svc.Deposit(c.Context(), userID, req.AmountCents)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	return c.JSON(w)
+}
@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// LogMailer пишет письма в лог вместо отправки.
+type LogMailer struct {
+	log *zap.SugaredLogger
+}
+
+func NewLogMailer(log *zap.SugaredLogger) *LogMailer {
+	return &LogMailer{log: log}
+}
+
+func (m *LogMailer) Send(_ context.Context, msg Message) error {
+	m.log.Infow("mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+var ErrInvalidAddress = errors.New("invalid email")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма пользователям. Для разработки есть LogMailer и FileMailer,
+// для прода подключается реализация поверх SMTP или API почтового провайдера.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ValidateAddress проверяет, что строка — голый адрес вида user@host.tld,
+// без имени и угловых скобок.
+func ValidateAddress(addr string) error {
+	a, err := mail.ParseAddress(addr)
+	if err != nil || a.Address != addr {
+		return ErrInvalidAddress
+	}
+	host := addr[strings.LastIndex(addr, "@")+1:]
+	// .invalid зарезервирован (RFC 2606) и используется для адресов удалённых пользователей
+	if !strings.Contains(host, ".") || strings.HasSuffix(host, ".invalid") {
+		return ErrInvalidAddress
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+package message_broker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"market/internal/events"
+)
+
+// Handler обрабатывает одно сообщение. Ошибка приводит к повтору с backoff,
+// ошибка, обёрнутая в Permanent, — сразу к отправке в dead-letter топик.
+type Handler func(ctx context.Context, msg kafka.Message) error
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent помечает ошибку как неисправимую: повторять обработку бессмысленно.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+type ConsumerConfig struct {
+	Brokers        []string
+	GroupID        string
+	MaxAttempts    int           // попыток обработки до отправки в DLQ
+	InitialBackoff time.Duration // пауза перед первым повтором, дальше удваивается
+	MaxBackoff     time.Duration
+	DLQSuffix      string // DLQ-топик = исходный топик + суффикс
+}
+
+// Consumer читает топики в составе consumer group и коммитит offset
+// только после успешной обработки (или отправки сообщения в DLQ).
+type Consumer struct {
+	cfg      ConsumerConfig
+	handlers map[string]Handler
+	dlq      Sender
+	log      *zap.SugaredLogger
+}
+
+func NewConsumer(cfg ConsumerConfig, dlq Sender, log *zap.SugaredLogger) *Consumer {
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 200 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Second
+	}
+	if cfg.DLQSuffix == "" {
+		cfg.DLQSuffix = ".dlq"
+	}
+	return &Consumer{cfg: cfg, handlers: map[string]Handler{}, dlq: dlq, log: log}
+}
+
+// Handle регистрирует обработчик топика. Вызывать до Run.
+func (c *Consumer) Handle(topic string, h Handler) {
+	c.handlers[topic] = h
+}
+
+// HandleJSON регистрирует типизированный обработчик: значение сообщения декодируется в T.
+// Ошибка декодирования считается неисправимой.
+func HandleJSON[T any](c *Consumer, topic string, fn func(ctx context.Context, v T, msg kafka.Message) error) {
+	c.Handle(topic, func(ctx context.Context, msg kafka.Message) error {
+		var v T
+		if err := json.Unmarshal(msg.Value, &v); err != nil {
+			return Permanent(fmt.Errorf("decode %s: %w", topic, err))
+		}
+		return fn(ctx, v, msg)
+	})
+}
+
+// HandleEvent регистрирует обработчик конкретного типа события. Несколько типов
+// могут жить в одном топике: сообщения других типов пропускаются.
+func HandleEvent[T events.Event](c *Consumer, reg *events.Registry, fn func(ctx context.Context, env events.Envelope, e T) error) {
+	var zero T
+	eventType := zero.EventType()
+	topic := reg.Topic(eventType)
+	next := c.handlers[topic]
+	c.Handle(topic, func(ctx context.Context, msg kafka.Message) error {
+		if headerValue(msg, HeaderEventType) != eventType {
+			if next != nil {
+				return next(ctx, msg)
+			}
+			return nil
+		}
+		env, e, err := events.Decode[T](msg.Value)
+		if err != nil {
+			return Permanent(fmt.Errorf("decode %s: %w", eventType, err))
+		}
+		return fn(ctx, env, e)
+	})
+}
+
+func headerValue(msg kafka.Message, key string) string {
+	for _, h := range msg.Headers {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Run блокируется до отмены ctx или фатальной ошибки чтения/коммита.
+func (c *Consumer) Run(ctx context.Context) error {
+	if len(c.handlers) == 0 {
+		return errors.New("consumer: no handlers registered")
+	}
+	topics := make([]string, 0, len(c.handlers))
+	for t := range c.handlers {
+		topics = append(topics, t)
+	}
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     c.cfg.Brokers,
+		GroupID:     c.cfg.GroupID,
+		GroupTopics: topics,
+		StartOffset: kafka.FirstOffset,
+	})
+	defer r.Close()
+
+	for {
+		msg, err := r.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("consumer: fetch: %w", err)
+		}
+		if err := c.process(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				// Offset не закоммичен — сообщение будет перечитано после рестарта
+				return nil
+			}
+			return err
+		}
+		if err := r.CommitMessages(ctx, msg); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("consumer: commit: %w", err)
+		}
+	}
+}
+
+// process вызывает обработчик с повторами; после исчерпания попыток
+// сообщение уходит в DLQ. Ошибка возвращается, только если и DLQ недоступен.
+func (c *Consumer) process(ctx context.Context, msg kafka.Message) error {
+	h := c.handlers[msg.Topic]
+	backoff := c.cfg.InitialBackoff
+	var err error
+	for attempt := 1; attempt <= c.cfg.MaxAttempts; attempt++ {
+		if err = h(ctx, msg); err == nil {
+			return nil
+		}
+		var perm permanentError
+		if errors.As(err, &perm) || attempt == c.cfg.MaxAttempts {
+			break
+		}
+		c.log.Warnw("consumer: handler failed, retrying",
+			"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, c.cfg.MaxBackoff)
+	}
+
+	c.log.Errorw("consumer: sending message to DLQ",
+		"topic", msg.Topic, "partition", msg.Partition, "offset", msg.Offset, "err", err)
+	return c.deadLetter(ctx, msg, err)
+}
+
+func (c *Consumer) deadLetter(ctx context.Context, msg kafka.Message, cause error) error {
+	if c.dlq == nil {
+		return fmt.Errorf("consumer: no DLQ configured, giving up on %s/%d@%d: %w", msg.Topic, msg.Partition, msg.Offset, cause)
+	}
+	headers := append([]kafka.Header(nil), msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "dlq_error", Value: []byte(cause.Error())},
+		kafka.Header{Key: "dlq_topic", Value: []byte(msg.Topic)},
+		kafka.Header{Key: "dlq_partition", Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: "dlq_offset", Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	err := c.dlq.Send(ctx, kafka.Message{
+		Topic:   msg.Topic + c.cfg.DLQSuffix,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err != nil {
+		return fmt.Errorf("consumer: dlq: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package message_broker
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+	"market/internal/events"
+)
+
+// Заголовки, которыми сопровождается каждое доменное событие.
+const (
+	HeaderEventID      = "event_id"
+	HeaderEventType    = "event_type"
+	HeaderEventVersion = "event_version"
+)
+
+// EnvelopeMessage собирает сообщение Kafka из конверта: топик по реестру, ключ — id агрегата.
+func EnvelopeMessage(reg *events.Registry, env events.Envelope) (kafka.Message, error) {
+	value, err := json.Marshal(env)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{
+		Topic:   reg.Topic(env.Type),
+		Key:     []byte(env.AggregateID),
+		Value:   value,
+		Headers: eventHeaders(env.ID, env.Type, env.Version),
+	}, nil
+}
+
+func eventHeaders(id, eventType string, version int) []kafka.Header {
+	return []kafka.Header{
+		{Key: HeaderEventID, Value: []byte(id)},
+		{Key: HeaderEventType, Value: []byte(eventType)},
+		{Key: HeaderEventVersion, Value: []byte(strconv.Itoa(version))},
+	}
+}
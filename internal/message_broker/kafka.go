@@ -2,7 +2,10 @@ package message_broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/segmentio/kafka-go"
@@ -10,71 +13,243 @@ import (
 )
 
 const (
-	TopicMy     = "my-topic"
 	BufferSize  = 100
 	WorkerCount = 10 // Количество воркеров для обработки сообщений
 )
 
-type Message struct {
-	message string
-	topic   string
+var ErrProducerClosed = errors.New("producer is closed")
+
+type ProducerConfig struct {
+	Brokers        []string
+	BufferSize     int // ёмкость очереди Produce; при заполнении Produce блокируется
+	Workers        int
+	MaxAttempts    int // попыток записи одного сообщения
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// DLQSuffix — куда отправлять сообщения после исчерпания попыток (топик + суффикс);
+	// пусто — без DLQ
+	DLQSuffix string
+	// OnDelivered и OnFailure вызываются из воркеров для сообщений, отправленных через Produce.
+	// OnFailure получает сообщение, которое так и не попало в свой топик (даже если ушло в DLQ).
+	OnDelivered func(msg kafka.Message)
+	OnFailure   func(msg kafka.Message, err error)
+	Log         *zap.SugaredLogger
+}
+
+// ProducerStats — счётчики с момента создания продюсера.
+type ProducerStats struct {
+	Enqueued     uint64 `json:"enqueued"`
+	Delivered    uint64 `json:"delivered"`
+	Retries      uint64 `json:"retries"`
+	Failed       uint64 `json:"failed"`
+	DeadLettered uint64 `json:"dead_lettered"`
 }
 
 type Producer struct {
 	writer *kafka.Writer
-	msgCh  chan Message
+	cfg    ProducerConfig
+	msgCh  chan kafka.Message
+
+	// mu защищает закрытие msgCh от параллельных Produce
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+	// ctx отменяется, если Shutdown не успел дождаться доставки
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	enqueued, delivered, retries, failed, deadLettered atomic.Uint64
 }
 
-func NewProducer(address []string) (*Producer, error) {
+func NewProducer(cfg ProducerConfig) (*Producer, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, errors.New("producer: no brokers configured")
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = BufferSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = WorkerCount
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 5
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 5 * time.Second
+	}
+	if cfg.Log == nil {
+		cfg.Log = zap.NewNop().Sugar()
+	}
 	w := &kafka.Writer{
-		Addr:         kafka.TCP(address...),
-		Balancer:     &kafka.LeastBytes{},
+		Addr: kafka.TCP(cfg.Brokers...),
+		// Партиция выбирается по ключу (id агрегата) — события одного агрегата идут по порядку
+		Balancer:     &kafka.Hash{},
 		WriteTimeout: 10 * time.Second,
 		ReadTimeout:  10 * time.Second,
+		RequiredAcks: kafka.RequireAll,
+		// Повторы делаем сами, с backoff и учётом MaxAttempts
+		MaxAttempts:  1,
+		BatchTimeout: 10 * time.Millisecond,
 	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	p := &Producer{
 		writer: w,
-		msgCh:  make(chan Message, BufferSize),
+		cfg:    cfg,
+		msgCh:  make(chan kafka.Message, cfg.BufferSize),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
 	}
-
-	p.StartWorker()
 	return p, nil
 }
 
-func (p *Producer) ProduceAsync(message, topic string) {
-	p.msgCh <- Message{message: message, topic: topic}
+// Produce ставит сообщение в очередь на асинхронную отправку. Если очередь заполнена,
+// вызов ждёт освобождения места или отмены ctx.
+func (p *Producer) Produce(ctx context.Context, msg kafka.Message) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrProducerClosed
+	}
+	select {
+	case p.msgCh <- msg:
+		p.enqueued.Add(1)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Send синхронно пишет сообщения с повторами и возвращает итоговую ошибку записи.
+func (p *Producer) Send(ctx context.Context, msgs ...kafka.Message) error {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return ErrProducerClosed
+	}
+	if err := p.write(ctx, msgs...); err != nil {
+		p.failed.Add(uint64(len(msgs)))
+		return err
+	}
+	p.delivered.Add(uint64(len(msgs)))
+	return nil
+}
+
+func (p *Producer) Stats() ProducerStats {
+	return ProducerStats{
+		Enqueued:     p.enqueued.Load(),
+		Delivered:    p.delivered.Load(),
+		Retries:      p.retries.Load(),
+		Failed:       p.failed.Load(),
+		DeadLettered: p.deadLettered.Load(),
+	}
+}
+
+// Shutdown перестаёт принимать сообщения, дожидается доставки очереди и закрывает writer.
+// Если ctx истёк раньше, недоставленные сообщения уходят в OnFailure.
+func (p *Producer) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.msgCh)
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		p.cancel()
+		<-done
+	}
+	p.cancel()
+	if cerr := p.writer.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (p *Producer) Close() {
+	_ = p.Shutdown(context.Background())
 }
 
 func (p *Producer) worker() {
-	for {
-		select {
-		case msg, ok := <-p.msgCh:
-			if !ok {
-				zap.L().Info("message channel closed, exiting worker")
-				return
+	defer p.wg.Done()
+	for msg := range p.msgCh {
+		err := p.write(p.ctx, msg)
+		if err == nil {
+			p.delivered.Add(1)
+			if p.cfg.OnDelivered != nil {
+				p.cfg.OnDelivered(msg)
 			}
-			err := p.writer.WriteMessages(context.Background(), kafka.Message{
-				Topic: msg.topic,
-				Value: []byte(msg.message),
-				Key:   nil, // Можно добавить ключ, если нужно
-			})
-			if err != nil {
-				zap.L().Error("failed to write message", zap.Error(err))
-				p.msgCh <- msg // Возвращаем сообщение обратно в канал для повторной попытки
+			continue
+		}
+		p.failed.Add(1)
+		p.cfg.Log.Errorw("producer: message not delivered", "topic", msg.Topic, "err", err)
+		if p.cfg.DLQSuffix != "" {
+			if dlqErr := p.deadLetter(msg, err); dlqErr != nil {
+				err = errors.Join(err, dlqErr)
 			}
-			fmt.Println("Message sent successfully:", msg.message)
+		}
+		if p.cfg.OnFailure != nil {
+			p.cfg.OnFailure(msg, err)
 		}
 	}
 }
 
-func (p *Producer) StartWorker() {
-	for i := 0; i < WorkerCount; i++ {
-		go p.worker()
+// write пишет сообщения, повторяя с экспоненциальной паузой до MaxAttempts раз.
+func (p *Producer) write(ctx context.Context, msgs ...kafka.Message) error {
+	backoff := p.cfg.InitialBackoff
+	var err error
+	for attempt := 1; attempt <= p.cfg.MaxAttempts; attempt++ {
+		if err = p.writer.WriteMessages(ctx, msgs...); err == nil {
+			return nil
+		}
+		if attempt == p.cfg.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+		p.retries.Add(1)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, p.cfg.MaxBackoff)
 	}
+	return fmt.Errorf("write failed after retries: %w", err)
 }
 
-func (p *Producer) Close() {
-	close(p.msgCh)
-	_ = p.writer.Close()
+func (p *Producer) deadLetter(msg kafka.Message, cause error) error {
+	headers := append([]kafka.Header(nil), msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: "dlq_error", Value: []byte(cause.Error())},
+		kafka.Header{Key: "dlq_topic", Value: []byte(msg.Topic)},
+	)
+	// Одна попытка без ретраев: если брокер недоступен, DLQ тоже не поможет
+	err := p.writer.WriteMessages(p.ctx, kafka.Message{
+		Topic:   msg.Topic + p.cfg.DLQSuffix,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+	if err == nil {
+		p.deadLettered.Add(1)
+	}
+	return err
 }
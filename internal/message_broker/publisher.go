@@ -0,0 +1,36 @@
+package message_broker
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"market/internal/events"
+)
+
+// KafkaPublisher публикует события напрямую в Kafka, минуя outbox.
+// Запись не участвует в транзакции БД: если транзакция потом откатится,
+// событие всё равно уйдёт. Для бизнес-изменений предпочтителен outbox.
+type KafkaPublisher struct {
+	sender   Sender
+	registry *events.Registry
+}
+
+func NewKafkaPublisher(sender Sender, registry *events.Registry) *KafkaPublisher {
+	return &KafkaPublisher{sender: sender, registry: registry}
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, evts ...events.Event) error {
+	msgs := make([]kafka.Message, 0, len(evts))
+	for _, e := range evts {
+		env, err := events.New(e)
+		if err != nil {
+			return err
+		}
+		msg, err := EnvelopeMessage(p.registry, env)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, msg)
+	}
+	return p.sender.Send(ctx, msgs...)
+}
@@ -0,0 +1,173 @@
+package message_broker
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"market/internal/domain"
+	"market/internal/events"
+)
+
+// OutboxStore — источник сообщений для Relay (реализуется repository.OutboxRepository).
+type OutboxStore interface {
+	FetchUnsent(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, ids ...int64) error
+	MarkFailed(ctx context.Context, reason string, ids ...int64) error
+}
+
+// Transactor выполняет fn в транзакции БД (реализуется repository.TxManager).
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Sender синхронно доставляет сообщения в Kafka.
+type Sender interface {
+	Send(ctx context.Context, msgs ...kafka.Message) error
+}
+
+type RelayConfig struct {
+	Interval  time.Duration // пауза между опросами пустого outbox
+	BatchSize int
+}
+
+// Relay переносит события из outbox в Kafka с гарантией at-least-once:
+// строка помечается отправленной только после успешной записи в брокер.
+type Relay struct {
+	store    OutboxStore
+	tx       Transactor
+	sender   Sender
+	registry *events.Registry
+	cfg      RelayConfig
+	log      *zap.SugaredLogger
+}
+
+func NewRelay(store OutboxStore, tx Transactor, sender Sender, registry *events.Registry, cfg RelayConfig, log *zap.SugaredLogger) *Relay {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 100
+	}
+	return &Relay{store: store, tx: tx, sender: sender, registry: registry, cfg: cfg, log: log}
+}
+
+// Run опрашивает outbox до отмены ctx.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		n, err := r.flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.log.Errorw("outbox relay: flush failed", "err", err)
+		}
+		// Полная пачка — скорее всего есть ещё, забираем без паузы
+		if err == nil && n == r.cfg.BatchSize {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// errBlockedByKey — сообщение не отправлялось: более раннее с тем же ключом не доставлено,
+// а порядок событий одного агрегата важнее скорости.
+var errBlockedByKey = errors.New("earlier message with the same key not delivered")
+
+// maxConsecutiveFailures — после стольких неудачных одиночных отправок подряд считаем,
+// что недоступен брокер, а не отдельные сообщения, и остаток пачки не отправляем.
+const maxConsecutiveFailures = 2
+
+// flush отправляет одну пачку и возвращает количество доставленных сообщений.
+// Отправленными помечаются только доставленные строки: недоставленное сообщение
+// не задерживает остальные (at-least-once, дубли допустимы, потери — нет).
+func (r *Relay) flush(ctx context.Context) (int, error) {
+	var sent int
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		batch, err := r.store.FetchUnsent(ctx, r.cfg.BatchSize)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		msgs := make([]kafka.Message, 0, len(batch))
+		for _, m := range batch {
+			msgs = append(msgs, kafka.Message{
+				Topic:   r.registry.Topic(m.EventType),
+				Key:     []byte(m.AggregateID),
+				Value:   m.Payload,
+				Headers: eventHeaders(m.EventID, m.EventType, m.EventVersion),
+			})
+		}
+		errs := r.deliver(ctx, msgs)
+
+		var sentIDs []int64
+		failed := map[string][]int64{} // причина -> строки
+		for i, m := range batch {
+			if errs[i] == nil {
+				sentIDs = append(sentIDs, m.ID)
+				continue
+			}
+			failed[errs[i].Error()] = append(failed[errs[i].Error()], m.ID)
+		}
+		if len(failed) > 0 {
+			r.log.Warnw("outbox relay: publish failed", "failed", len(batch)-len(sentIDs), "batch", len(batch))
+		}
+		for reason, ids := range failed {
+			if err := r.store.MarkFailed(ctx, reason, ids...); err != nil {
+				return err
+			}
+		}
+		sent = len(sentIDs)
+		return r.store.MarkSent(ctx, sentIDs...)
+	})
+	return sent, err
+}
+
+// deliver отправляет пачку и возвращает ошибку для каждого сообщения (nil — доставлено).
+func (r *Relay) deliver(ctx context.Context, msgs []kafka.Message) []error {
+	errs := make([]error, len(msgs))
+	err := r.sender.Send(ctx, msgs...)
+	if err == nil {
+		return errs
+	}
+	// Брокер ответил по каждому сообщению
+	var werr kafka.WriteErrors
+	if errors.As(err, &werr) && len(werr) == len(msgs) {
+		return werr
+	}
+	if len(msgs) == 1 || ctx.Err() != nil {
+		return fill(errs, err)
+	}
+	// Ошибка на всю пачку (слишком большое сообщение, неизвестный топик и т. п.):
+	// отправляем по одному, чтобы найти виновное сообщение.
+	blocked := map[string]bool{}
+	consecutive := 0
+	for i, m := range msgs {
+		key := m.Topic + "/" + string(m.Key)
+		switch {
+		case consecutive >= maxConsecutiveFailures || ctx.Err() != nil:
+			errs[i] = err
+		case blocked[key]:
+			errs[i] = errBlockedByKey
+		default:
+			if errs[i] = r.sender.Send(ctx, m); errs[i] != nil {
+				blocked[key] = true
+				consecutive++
+			} else {
+				consecutive = 0
+			}
+		}
+	}
+	return errs
+}
+
+func fill(errs []error, err error) []error {
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
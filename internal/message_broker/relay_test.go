@@ -0,0 +1,133 @@
+package message_broker
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+	"market/internal/domain"
+	"market/internal/events"
+)
+
+type fakeStore struct {
+	batch  []domain.OutboxMessage
+	sent   []int64
+	failed map[int64]string
+}
+
+func (s *fakeStore) FetchUnsent(context.Context, int) ([]domain.OutboxMessage, error) {
+	return s.batch, nil
+}
+
+func (s *fakeStore) MarkSent(_ context.Context, ids ...int64) error {
+	s.sent = append(s.sent, ids...)
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(_ context.Context, reason string, ids ...int64) error {
+	for _, id := range ids {
+		s.failed[id] = reason
+	}
+	return nil
+}
+
+type noTx struct{}
+
+func (noTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error { return fn(ctx) }
+
+// fakeSender отклоняет сообщения с payload из bad: пачку целиком (как MessageTooLargeError)
+// или поштучно через kafka.WriteErrors.
+type fakeSender struct {
+	bad       map[string]bool
+	writeErrs bool
+	down      bool
+	calls     int
+}
+
+var errBad = errors.New("bad message")
+
+func (f *fakeSender) Send(_ context.Context, msgs ...kafka.Message) error {
+	f.calls++
+	if f.down {
+		return errors.New("broker unavailable")
+	}
+	werr := make(kafka.WriteErrors, len(msgs))
+	failed := false
+	for i, m := range msgs {
+		if f.bad[string(m.Value)] {
+			werr[i] = errBad
+			failed = true
+		}
+	}
+	if !failed {
+		return nil
+	}
+	if f.writeErrs {
+		return werr
+	}
+	return errBad
+}
+
+func outboxBatch(aggregates ...string) []domain.OutboxMessage {
+	out := make([]domain.OutboxMessage, len(aggregates))
+	for i, a := range aggregates {
+		out[i] = domain.OutboxMessage{ID: int64(i + 1), EventType: "product.updated", AggregateID: a, Payload: []byte(a + "#" + string(rune('0'+i)))}
+	}
+	return out
+}
+
+func runFlush(t *testing.T, store *fakeStore, sender *fakeSender) int {
+	t.Helper()
+	r := NewRelay(store, noTx{}, sender, events.NewRegistry(nil, "market."), RelayConfig{BatchSize: 10}, zap.NewNop().Sugar())
+	n, err := r.flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestRelayFlushSkipsRejectedMessages(t *testing.T) {
+	for _, writeErrs := range []bool{true, false} {
+		store := &fakeStore{batch: outboxBatch("1", "2", "3", "4"), failed: map[int64]string{}}
+		sender := &fakeSender{bad: map[string]bool{"2#1": true}, writeErrs: writeErrs}
+		if n := runFlush(t, store, sender); n != 3 {
+			t.Errorf("writeErrors=%v: sent %d, want 3", writeErrs, n)
+		}
+		if !slices.Equal(store.sent, []int64{1, 3, 4}) {
+			t.Errorf("writeErrors=%v: sent ids %v, want [1 3 4]", writeErrs, store.sent)
+		}
+		if store.failed[2] != errBad.Error() || len(store.failed) != 1 {
+			t.Errorf("writeErrors=%v: failed %v, want only 2", writeErrs, store.failed)
+		}
+	}
+}
+
+func TestRelayFlushKeepsOrderWithinAggregate(t *testing.T) {
+	store := &fakeStore{batch: outboxBatch("1", "2", "1"), failed: map[int64]string{}}
+	sender := &fakeSender{bad: map[string]bool{"1#0": true}}
+	runFlush(t, store, sender)
+	if !slices.Equal(store.sent, []int64{2}) {
+		t.Fatalf("sent ids %v, want [2]", store.sent)
+	}
+	if store.failed[3] != errBlockedByKey.Error() {
+		t.Fatalf("message 3: %q, want blocked by key", store.failed[3])
+	}
+}
+
+func TestRelayFlushStopsWhenBrokerDown(t *testing.T) {
+	store := &fakeStore{batch: outboxBatch("1", "2", "3", "4", "5"), failed: map[int64]string{}}
+	sender := &fakeSender{down: true}
+	if n := runFlush(t, store, sender); n != 0 {
+		t.Fatalf("sent %d, want 0", n)
+	}
+	if len(store.failed) != 5 {
+		t.Fatalf("failed %v, want all", store.failed)
+	}
+	// пачка и maxConsecutiveFailures одиночных попыток, а не по попытке на сообщение
+	if want := 1 + maxConsecutiveFailures; sender.calls != want {
+		t.Fatalf("Send called %d times, want %d", sender.calls, want)
+	}
+}
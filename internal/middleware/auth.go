@@ -1,32 +1,85 @@
 package middleware
 
 import (
+	"context"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"market/internal/domain"
 	"market/internal/utils"
 )
 
+// TokenRevocation сообщает, отозван ли уже проверенный по подписи токен.
+type TokenRevocation interface {
+	IsRevoked(ctx context.Context, claims *utils.AuthClaims) (bool, error)
+}
+
+// APIKeyAuthenticator проверяет ключ из заголовка X-API-Key. nil без ошибки —
+// ключ недействителен.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (*domain.Actor, error)
+}
+
 type AuthConfig struct {
-	JWTSecret string
+	Keys  *utils.KeySet
+	Token utils.JWTOptions
+	// Revocation — необязательная проверка отзыва (service.RevocationService)
+	Revocation TokenRevocation
+	// APIKeys — если задан, вместо JWT принимается X-API-Key (service.APIKeyService)
+	APIKeys APIKeyAuthenticator
 }
 
 const CtxUserID = "uid"
 const CtxUserRole = "role"
 
+// CtxAuthTime — время ввода учётных данных (time.Time) из claim auth_time; задаётся только при входе по JWT
+const CtxAuthTime = "auth_time"
+
+// CtxScopes — области API-ключа ([]domain.Permission); при входе по JWT не задаётся
+const CtxScopes = "scopes"
+
+const HeaderAPIKey = "X-API-Key"
+
 func AuthRequired(cfg AuthConfig) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if key := c.Get(HeaderAPIKey); key != "" && cfg.APIKeys != nil {
+			actor, err := cfg.APIKeys.Authenticate(c.Context(), key)
+			if err != nil {
+				return err
+			}
+			if actor == nil {
+				return fiber.NewError(fiber.StatusUnauthorized, "invalid api key")
+			}
+			c.Locals(CtxUserID, actor.UserID)
+			c.Locals(CtxUserRole, string(actor.Role))
+			c.Locals(CtxScopes, actor.Scopes)
+			setActor(c, actor.UserID, string(actor.Role))
+			return c.Next()
+		}
 		auth := c.Get("Authorization")
 		if auth == "" || !strings.HasPrefix(auth, "Bearer ") {
 			return fiber.NewError(fiber.StatusUnauthorized, "missing bearer token")
 		}
 		token := strings.TrimPrefix(auth, "Bearer ")
-		claims, err := utils.ParseJWT(token, cfg.JWTSecret)
+		claims, err := utils.ParseJWT(token, cfg.Keys, cfg.Token)
 		if err != nil {
 			return fiber.NewError(fiber.StatusUnauthorized, "invalid token")
 		}
+		if cfg.Revocation != nil {
+			revoked, err := cfg.Revocation.IsRevoked(c.Context(), claims)
+			if err != nil {
+				return err
+			}
+			if revoked {
+				return fiber.NewError(fiber.StatusUnauthorized, "token revoked")
+			}
+		}
 		c.Locals(CtxUserID, claims.UserID)
 		c.Locals(CtxUserRole, claims.Role)
+		if claims.AuthTime != nil {
+			c.Locals(CtxAuthTime, claims.AuthTime.Time)
+		}
+		setActor(c, claims.UserID, claims.Role)
 		return c.Next()
 	}
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"market/internal/domain"
+)
+
+// RequestMeta кладёт в контекст запроса domain.RequestMeta с IP и X-Request-ID
+// (ставится middleware requestid раньше по цепочке). Пользователя дописывает
+// AuthRequired. Сервисы читают его через domain.RequestMetaFrom(ctx).
+func RequestMeta() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Context().SetUserValue(domain.RequestMetaKey{}, &domain.RequestMeta{
+			IP:        c.IP(),
+			RequestID: c.GetRespHeader(fiber.HeaderXRequestID),
+		})
+		return c.Next()
+	}
+}
+
+// setActor дописывает пользователя в RequestMeta, если он есть в запросе.
+func setActor(c *fiber.Ctx, userID int64, role string) {
+	if m, ok := c.Context().UserValue(domain.RequestMetaKey{}).(*domain.RequestMeta); ok {
+		m.ActorID = userID
+		m.ActorRole = domain.Role(role)
+	}
+}
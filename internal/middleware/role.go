@@ -1,13 +1,28 @@
 package middleware
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"github.com/gofiber/fiber/v2"
+	"market/internal/domain"
+)
 
-func RequireSeller() fiber.Handler {
+// RequirePermission пропускает запрос, если роль из токена (с учётом областей
+// API-ключа) даёт хотя бы одно из прав.
+func RequirePermission(perms ...domain.Permission) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		role, _ := c.Locals(CtxUserRole).(string)
-		if role != "seller" {
-			return fiber.NewError(fiber.StatusForbidden, "seller role required")
+		actor := ActorFrom(c)
+		for _, p := range perms {
+			if actor.Can(p) {
+				return c.Next()
+			}
 		}
-		return c.Next()
+		return fiber.NewError(fiber.StatusForbidden, "permission required")
 	}
 }
+
+// ActorFrom собирает domain.Actor из данных, положенных Auth в Locals.
+func ActorFrom(c *fiber.Ctx) domain.Actor {
+	id, _ := c.Locals(CtxUserID).(int64)
+	role, _ := c.Locals(CtxUserRole).(string)
+	scopes, _ := c.Locals(CtxScopes).([]domain.Permission)
+	return domain.Actor{UserID: id, Role: domain.Role(role), Scopes: scopes}
+}
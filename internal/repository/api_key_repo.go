@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, k *domain.APIKey, keyHash string) error
+	GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error)
+	ListByUser(ctx context.Context, userID int64) ([]domain.APIKey, error)
+	CountByUser(ctx context.Context, userID int64) (int, error)
+	Delete(ctx context.Context, userID, id int64) error
+	// TouchLastUsed обновляет last_used_at не чаще раза в минуту, чтобы не писать на каждый запрос.
+	TouchLastUsed(ctx context.Context, id int64) error
+}
+
+type apiKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAPIKeyRepository(pool *pgxpool.Pool) APIKeyRepository {
+	return &apiKeyRepo{pool: pool}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, scopes, expires_at, last_used_at, created_at`
+
+func scanAPIKey(row pgx.Row) (*domain.APIKey, error) {
+	var k domain.APIKey
+	var scopes []string
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.ExpiresAt, &k.LastUsedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	k.Scopes = make([]domain.Permission, len(scopes))
+	for i, s := range scopes {
+		k.Scopes[i] = domain.Permission(s)
+	}
+	return &k, nil
+}
+
+func (r *apiKeyRepo) Create(ctx context.Context, k *domain.APIKey, keyHash string) error {
+	scopes := make([]string, len(k.Scopes))
+	for i, s := range k.Scopes {
+		scopes[i] = string(s)
+	}
+	return conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, k.UserID, k.Name, k.Prefix, keyHash, scopes, k.ExpiresAt).Scan(&k.ID, &k.CreatedAt)
+}
+
+func (r *apiKeyRepo) GetByHash(ctx context.Context, keyHash string) (*domain.APIKey, error) {
+	k, err := scanAPIKey(conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE key_hash = $1
+	`, keyHash))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	return k, err
+}
+
+func (r *apiKeyRepo) ListByUser(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *k)
+	}
+	return out, rows.Err()
+}
+
+func (r *apiKeyRepo) CountByUser(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := conn(ctx, r.pool).QueryRow(ctx, `SELECT COUNT(*) FROM api_keys WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+func (r *apiKeyRepo) Delete(ctx context.Context, userID, id int64) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func (r *apiKeyRepo) TouchLastUsed(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+	`, id)
+	return err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+type AuditFilter struct {
+	Limit      int32
+	Offset     int32
+	ActorID    int64  // 0 — любой
+	EntityType string // "" — любой
+	EntityID   string // учитывается вместе с EntityType
+	Action     string
+	From       *time.Time // включительно
+	To         *time.Time // не включительно
+}
+
+type AuditRepository interface {
+	Insert(ctx context.Context, e *domain.AuditEntry) error
+	List(ctx context.Context, f AuditFilter) ([]domain.AuditEntry, error)
+}
+
+type auditRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepository(pool *pgxpool.Pool) AuditRepository {
+	return &auditRepo{pool: pool}
+}
+
+func (r *auditRepo) Insert(ctx context.Context, e *domain.AuditEntry) error {
+	var diff any
+	if len(e.Diff) > 0 {
+		diff = string(e.Diff)
+	}
+	return conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO audit_log (actor_id, actor_role, action, entity_type, entity_id, diff, ip, request_id)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6::jsonb, NULLIF($7, ''), NULLIF($8, ''))
+		RETURNING id, occurred_at
+	`, e.ActorID, e.ActorRole, e.Action, e.EntityType, e.EntityID, diff, e.IP, e.RequestID).Scan(&e.ID, &e.OccurredAt)
+}
+
+func (r *auditRepo) List(ctx context.Context, f AuditFilter) ([]domain.AuditEntry, error) {
+	var where []string
+	args := []any{}
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.ActorID != 0 {
+		add("actor_id = $%d", f.ActorID)
+	}
+	if f.EntityType != "" {
+		add("entity_type = $%d", f.EntityType)
+		if f.EntityID != "" {
+			add("entity_id = $%d", f.EntityID)
+		}
+	}
+	if f.Action != "" {
+		add("action = $%d", f.Action)
+	}
+	if f.From != nil {
+		add("occurred_at >= $%d", *f.From)
+	}
+	if f.To != nil {
+		add("occurred_at < $%d", *f.To)
+	}
+	q := `
+		SELECT id, occurred_at, actor_id, COALESCE(actor_role, ''), action, entity_type,
+		       COALESCE(entity_id, ''), diff, COALESCE(ip, ''), COALESCE(request_id, '')
+		FROM audit_log
+	`
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	args = append(args, f.Limit, f.Offset)
+	q += fmt.Sprintf(" ORDER BY occurred_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.pool).Query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.AuditEntry{}
+	for rows.Next() {
+		var e domain.AuditEntry
+		var diff []byte
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorID, &e.ActorRole, &e.Action, &e.EntityType,
+			&e.EntityID, &diff, &e.IP, &e.RequestID); err != nil {
+			return nil, err
+		}
+		e.Diff = diff
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+type BalanceRepository interface {
+	GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error)
+	Deposit(ctx context.Context, userID, amount int64) (*domain.Wallet, error)
+	Withdraw(ctx context.Context, userID, amount int64) (*domain.Wallet, error)
+	Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error
+}
+
+type balanceRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewBalanceRepository(pool *pgxpool.Pool) BalanceRepository {
+	return &balanceRepo{pool: pool}
+}
+
+func (r *balanceRepo) GetByUserID(ctx context.Context, userID int64) (*domain.Wallet, error) {
+	w := domain.Wallet{UserID: userID}
+	var balanceID *int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT u.balance_id, COALESCE(b.balance, 0)
+		FROM users u
+		LEFT JOIN balance_users b ON b.id = u.balance_id
+		WHERE u.id = $1
+	`, userID).Scan(&balanceID, &w.BalanceCents)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if balanceID != nil {
+		w.ID = *balanceID
+	}
+	return &w, nil
+}
+
+func (r *balanceRepo) Deposit(ctx context.Context, userID, amount int64) (*domain.Wallet, error) {
+	var w *domain.Wallet
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		wallets, err := lockWallets(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		w = wallets[userID]
+		return addBalance(ctx, tx, w, amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *balanceRepo) Withdraw(ctx context.Context, userID, amount int64) (*domain.Wallet, error) {
+	var w *domain.Wallet
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		wallets, err := lockWallets(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		w = wallets[userID]
+		return addBalance(ctx, tx, w, -amount)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (r *balanceRepo) Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		wallets, err := lockWallets(ctx, tx, fromUserID, toUserID)
+		if err != nil {
+			return err
+		}
+		if err := addBalance(ctx, tx, wallets[fromUserID], -amount); err != nil {
+			return err
+		}
+		return addBalance(ctx, tx, wallets[toUserID], amount)
+	})
+}
+
+// lockWallets блокирует кошельки пользователей (SELECT ... FOR UPDATE) в порядке
+// возрастания user id, чтобы параллельные переводы не ловили дедлок.
+// Пользователю без кошелька он создаётся в той же транзакции.
+func lockWallets(ctx context.Context, tx pgx.Tx, userIDs ...int64) (map[int64]*domain.Wallet, error) {
+	ids := append([]int64(nil), userIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	out := make(map[int64]*domain.Wallet, len(ids))
+	for _, uid := range ids {
+		if _, ok := out[uid]; ok {
+			continue
+		}
+		var balanceID *int64
+		err := tx.QueryRow(ctx, `
+			SELECT balance_id FROM users WHERE id = $1 FOR NO KEY UPDATE
+		`, uid).Scan(&balanceID)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+		w := &domain.Wallet{UserID: uid}
+		if balanceID == nil {
+			if err := tx.QueryRow(ctx, `
+				INSERT INTO balance_users DEFAULT VALUES RETURNING id, balance
+			`).Scan(&w.ID, &w.BalanceCents); err != nil {
+				return nil, err
+			}
+			if _, err := tx.Exec(ctx, `UPDATE users SET balance_id = $2 WHERE id = $1`, uid, w.ID); err != nil {
+				return nil, err
+			}
+		} else if err := tx.QueryRow(ctx, `
+			SELECT id, balance FROM balance_users WHERE id = $1 FOR UPDATE
+		`, *balanceID).Scan(&w.ID, &w.BalanceCents); err != nil {
+			return nil, err
+		}
+		out[uid] = w
+	}
+	return out, nil
+}
+
+// addBalance меняет баланс заблокированного кошелька на delta (может быть отрицательной).
+func addBalance(ctx context.Context, tx pgx.Tx, w *domain.Wallet, delta int64) error {
+	if w.BalanceCents+delta < 0 {
+		return ErrInsufficientFunds
+	}
+	return tx.QueryRow(ctx, `
+		UPDATE balance_users SET balance = balance + $2 WHERE id = $1 RETURNING balance
+	`, w.ID, delta).Scan(&w.BalanceCents)
+}
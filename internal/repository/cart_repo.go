@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrCartItemNotFound = errors.New("cart item not found")
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+type CartRepository interface {
+	ListItems(ctx context.Context, userID int64) ([]domain.CartItem, error)
+	GetQuantity(ctx context.Context, userID, productID int64) (int, error)
+	SetItem(ctx context.Context, userID, productID int64, quantity int) error
+	AddItem(ctx context.Context, userID, productID int64, quantity int) error
+	RemoveItem(ctx context.Context, userID, productID int64) error
+	Clear(ctx context.Context, userID int64) error
+}
+
+type cartRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewCartRepository(pool *pgxpool.Pool) CartRepository {
+	return &cartRepo{pool: pool}
+}
+
+func (r *cartRepo) ListItems(ctx context.Context, userID int64) ([]domain.CartItem, error) {
+	// Товары заблокированных продавцов скрыты, но остаются в корзине: после снятия
+	// блокировки они вернутся
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT p.id, p.name, p.price_cents, ci.quantity, p.stock
+		FROM carts c
+		JOIN cart_items ci ON ci.cart_id = c.id
+		JOIN products p ON p.id = ci.product_id
+		WHERE c.user_id = $1 AND `+visibleSellerOf("p")+`
+		ORDER BY ci.added_at, p.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []domain.CartItem{}
+	for rows.Next() {
+		var it domain.CartItem
+		if err := rows.Scan(&it.ProductID, &it.Name, &it.PriceCents, &it.Quantity, &it.Stock); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+func (r *cartRepo) GetQuantity(ctx context.Context, userID, productID int64) (int, error) {
+	var qty int
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT ci.quantity
+		FROM carts c
+		JOIN cart_items ci ON ci.cart_id = c.id
+		WHERE c.user_id = $1 AND ci.product_id = $2
+	`, userID, productID).Scan(&qty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	return qty, err
+}
+
+func (r *cartRepo) upsertCart(ctx context.Context, tx pgx.Tx, userID int64) (int64, error) {
+	var cartID int64
+	err := tx.QueryRow(ctx, `
+		INSERT INTO carts (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET updated_at = NOW()
+		RETURNING id
+	`, userID).Scan(&cartID)
+	return cartID, err
+}
+
+func (r *cartRepo) SetItem(ctx context.Context, userID, productID int64, quantity int) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		cartID, err := r.upsertCart(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(ctx, `
+			INSERT INTO cart_items (cart_id, product_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = EXCLUDED.quantity
+		`, cartID, productID, quantity)
+		return err
+	})
+}
+
+// AddItem атомарно прибавляет quantity к позиции корзины. Остаток проверяется
+// в том же запросе, поэтому параллельные добавления не теряются и не превышают stock.
+func (r *cartRepo) AddItem(ctx context.Context, userID, productID int64, quantity int) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		cartID, err := r.upsertCart(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		ct, err := tx.Exec(ctx, `
+			INSERT INTO cart_items (cart_id, product_id, quantity)
+			SELECT $1, p.id, $3 FROM products p WHERE p.id = $2 AND p.stock >= $3
+			ON CONFLICT (cart_id, product_id) DO UPDATE
+				SET quantity = cart_items.quantity + EXCLUDED.quantity
+				WHERE cart_items.quantity + EXCLUDED.quantity <= (
+					SELECT stock FROM products WHERE id = EXCLUDED.product_id
+				)
+		`, cartID, productID, quantity)
+		if err != nil {
+			return err
+		}
+		if ct.RowsAffected() == 0 {
+			return ErrInsufficientStock
+		}
+		return nil
+	})
+}
+
+func (r *cartRepo) RemoveItem(ctx context.Context, userID, productID int64) error {
+	ct, err := conn(ctx, r.pool).Exec(ctx, `
+		DELETE FROM cart_items ci
+		USING carts c
+		WHERE ci.cart_id = c.id AND c.user_id = $1 AND ci.product_id = $2
+	`, userID, productID)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (r *cartRepo) Clear(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		DELETE FROM cart_items ci
+		USING carts c
+		WHERE ci.cart_id = c.id AND c.user_id = $1
+	`, userID)
+	return err
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrVerificationTokenNotFound = errors.New("verification token not found")
+
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, t *domain.EmailVerificationToken, tokenHash string) error
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error)
+	MarkUsed(ctx context.Context, id int64) error
+	// InvalidateForUser гасит все неиспользованные токены пользователя (при повторной отправке).
+	InvalidateForUser(ctx context.Context, userID int64) error
+	LastSentAt(ctx context.Context, userID int64) (*time.Time, error)
+}
+
+type emailVerificationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewEmailVerificationRepository(pool *pgxpool.Pool) EmailVerificationRepository {
+	return &emailVerificationRepo{pool: pool}
+}
+
+func (r *emailVerificationRepo) Create(ctx context.Context, t *domain.EmailVerificationToken, tokenHash string) error {
+	return conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, t.UserID, t.Email, tokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *emailVerificationRepo) GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.EmailVerificationToken, error) {
+	var t domain.EmailVerificationToken
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT id, user_id, email, expires_at, created_at, used_at
+		FROM email_verification_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&t.ID, &t.UserID, &t.Email, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrVerificationTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *emailVerificationRepo) MarkUsed(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE email_verification_tokens SET used_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *emailVerificationRepo) InvalidateForUser(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	return err
+}
+
+func (r *emailVerificationRepo) LastSentAt(ctx context.Context, userID int64) (*time.Time, error) {
+	var ts *time.Time
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT MAX(created_at) FROM email_verification_tokens WHERE user_id = $1
+	`, userID).Scan(&ts)
+	return ts, err
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrIdentityNotFound = errors.New("identity not found")
+var ErrOIDCStateNotFound = errors.New("oidc state not found")
+var ErrIdentityExists = errors.New("identity already linked")
+
+type IdentityRepository interface {
+	Get(ctx context.Context, provider, subject string) (*domain.Identity, error)
+	Create(ctx context.Context, ident *domain.Identity) error
+	DeleteForUser(ctx context.Context, userID int64) error
+	SaveState(ctx context.Context, st *domain.OIDCState) error
+	// TakeState удаляет и возвращает state: каждый можно использовать один раз.
+	TakeState(ctx context.Context, state string) (*domain.OIDCState, error)
+}
+
+type identityRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdentityRepository(pool *pgxpool.Pool) IdentityRepository {
+	return &identityRepo{pool: pool}
+}
+
+func (r *identityRepo) Get(ctx context.Context, provider, subject string) (*domain.Identity, error) {
+	var i domain.Identity
+	var email *string
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT id, user_id, provider, subject, email, created_at
+		FROM identities
+		WHERE provider = $1 AND subject = $2
+	`, provider, subject).Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &email, &i.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrIdentityNotFound
+		}
+		return nil, err
+	}
+	if email != nil {
+		i.Email = *email
+	}
+	return &i, nil
+}
+
+func (r *identityRepo) Create(ctx context.Context, ident *domain.Identity) error {
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+		RETURNING id, created_at
+	`, ident.UserID, ident.Provider, ident.Subject, ident.Email).Scan(&ident.ID, &ident.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrIdentityExists
+	}
+	return err
+}
+
+func (r *identityRepo) DeleteForUser(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM identities WHERE user_id = $1`, userID)
+	return err
+}
+
+func (r *identityRepo) SaveState(ctx context.Context, st *domain.OIDCState) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// Заодно убираем брошенные входы
+		if _, err := tx.Exec(ctx, `DELETE FROM oidc_states WHERE expires_at < NOW()`); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO oidc_states (state, provider, nonce, code_verifier, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+		`, st.State, st.Provider, st.Nonce, st.CodeVerifier, st.ExpiresAt)
+		return err
+	})
+}
+
+func (r *identityRepo) TakeState(ctx context.Context, state string) (*domain.OIDCState, error) {
+	var st domain.OIDCState
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		DELETE FROM oidc_states WHERE state = $1
+		RETURNING state, provider, nonce, code_verifier, expires_at
+	`, state).Scan(&st.State, &st.Provider, &st.Nonce, &st.CodeVerifier, &st.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOIDCStateNotFound
+		}
+		return nil, err
+	}
+	return &st, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+type LoginAttemptRepository interface {
+	// GetForUpdate блокирует строку ключа до конца транзакции; если строки нет,
+	// создаёт пустую (Failures = 0). Вызывается внутри TxManager.WithinTx.
+	GetForUpdate(ctx context.Context, key string) (*domain.LoginAttempt, error)
+	Save(ctx context.Context, a *domain.LoginAttempt) error
+	// Refund снимает одну засчитанную попытку. Если задержка с тех пор не менялась
+	// (равна block), она возвращается к prevBlock.
+	Refund(ctx context.Context, key string, block, prevBlock *time.Time) error
+	Reset(ctx context.Context, key string) error
+	// DeleteExpired удаляет ключи без неудач за window и без действующей блокировки.
+	DeleteExpired(ctx context.Context, window time.Duration) (int64, error)
+}
+
+type loginAttemptRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewLoginAttemptRepository(pool *pgxpool.Pool) LoginAttemptRepository {
+	return &loginAttemptRepo{pool: pool}
+}
+
+func (r *loginAttemptRepo) GetForUpdate(ctx context.Context, key string) (*domain.LoginAttempt, error) {
+	q := conn(ctx, r.pool)
+	if _, err := q.Exec(ctx, `
+		INSERT INTO login_attempts (key) VALUES ($1) ON CONFLICT (key) DO NOTHING
+	`, key); err != nil {
+		return nil, err
+	}
+	a := domain.LoginAttempt{Key: key}
+	err := q.QueryRow(ctx, `
+		SELECT failures, last_failure_at, blocked_until
+		FROM login_attempts
+		WHERE key = $1
+		FOR UPDATE
+	`, key).Scan(&a.Failures, &a.LastFailureAt, &a.BlockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+func (r *loginAttemptRepo) Save(ctx context.Context, a *domain.LoginAttempt) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE login_attempts SET failures = $2, last_failure_at = $3, blocked_until = $4
+		WHERE key = $1
+	`, a.Key, a.Failures, a.LastFailureAt, a.BlockedUntil)
+	return err
+}
+
+func (r *loginAttemptRepo) Refund(ctx context.Context, key string, block, prevBlock *time.Time) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE login_attempts SET
+			failures = GREATEST(failures - 1, 0),
+			blocked_until = CASE WHEN blocked_until IS NOT DISTINCT FROM $2 THEN $3 ELSE blocked_until END
+		WHERE key = $1
+	`, key, block, prevBlock)
+	return err
+}
+
+func (r *loginAttemptRepo) Reset(ctx context.Context, key string) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM login_attempts WHERE key = $1`, key)
+	return err
+}
+
+func (r *loginAttemptRepo) DeleteExpired(ctx context.Context, window time.Duration) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		DELETE FROM login_attempts
+		WHERE last_failure_at < NOW() - make_interval(secs => $1)
+		  AND (blocked_until IS NULL OR blocked_until < NOW())
+	`, window.Seconds())
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrTOTPNotFound = errors.New("totp not configured")
+
+type MFARepository interface {
+	GetTOTP(ctx context.Context, userID int64) (*domain.TOTP, error)
+	// SavePendingTOTP сохраняет новый неподтверждённый секрет; подключённый TOTP не трогает.
+	SavePendingTOTP(ctx context.Context, userID int64, secretEncrypted []byte) (bool, error)
+	EnableTOTP(ctx context.Context, userID int64) error
+	// UseStep атомарно помечает шаг использованным; false — код с этим или более поздним шагом уже был.
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+	DeleteTOTP(ctx context.Context, userID int64) error
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error)
+}
+
+type mfaRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewMFARepository(pool *pgxpool.Pool) MFARepository {
+	return &mfaRepo{pool: pool}
+}
+
+func (r *mfaRepo) GetTOTP(ctx context.Context, userID int64) (*domain.TOTP, error) {
+	var t domain.TOTP
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT user_id, secret_encrypted, enabled_at, last_used_step, created_at
+		FROM user_totp
+		WHERE user_id = $1
+	`, userID).Scan(&t.UserID, &t.SecretEncrypted, &t.EnabledAt, &t.LastUsedStep, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTOTPNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *mfaRepo) SavePendingTOTP(ctx context.Context, userID int64, secretEncrypted []byte) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO user_totp (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+			SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, created_at = NOW()
+			WHERE user_totp.enabled_at IS NULL
+	`, userID, secretEncrypted)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *mfaRepo) EnableTOTP(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE user_totp SET enabled_at = NOW() WHERE user_id = $1 AND enabled_at IS NULL
+	`, userID)
+	return err
+}
+
+func (r *mfaRepo) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE user_totp SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *mfaRepo) DeleteTOTP(ctx context.Context, userID int64) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID)
+		return err
+	})
+}
+
+func (r *mfaRepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	return withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, `
+			INSERT INTO mfa_recovery_codes (user_id, code_hash)
+			SELECT $1, unnest($2::text[])
+		`, userID, codeHashes)
+		return err
+	})
+}
+
+func (r *mfaRepo) UseRecoveryCode(ctx context.Context, userID int64, codeHash string) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrOrderNotFound = errors.New("order not found")
+var ErrOwnProduct = errors.New("cannot buy own product")
+var ErrOrderStatusConflict = errors.New("order status changed concurrently")
+
+// StatusChange описывает переход заказа и его денежные/складские последствия.
+type StatusChange struct {
+	OrderID int64
+	From    domain.OrderStatus // ожидаемый текущий статус
+	To      domain.OrderStatus
+	ActorID int64
+	Refund  bool // вернуть деньги от продавца покупателю
+	Restock bool // вернуть позиции на склад
+}
+
+type OrderRepository interface {
+	// Checkout атомарно списывает остатки и деньги покупателя, зачисляет их продавцам
+	// и создаёт по заказу на каждого продавца. При clearCart купленные товары
+	// убираются из корзины в той же транзакции.
+	Checkout(ctx context.Context, buyerID int64, lines []domain.OrderLine, clearCart bool) ([]domain.Order, error)
+	GetByID(ctx context.Context, id int64) (*domain.Order, error)
+	ListByBuyer(ctx context.Context, buyerID int64, limit, offset int32) ([]domain.Order, error)
+	ListBySeller(ctx context.Context, sellerID int64, status domain.OrderStatus, limit, offset int32) ([]domain.Order, error)
+	ChangeStatus(ctx context.Context, ch StatusChange) (*domain.Order, error)
+	History(ctx context.Context, orderID int64) ([]domain.OrderStatusChange, error)
+}
+
+type orderRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOrderRepository(pool *pgxpool.Pool) OrderRepository {
+	return &orderRepo{pool: pool}
+}
+
+type lockedProduct struct {
+	id         int64
+	sellerID   int64
+	name       string
+	priceCents int64
+	stock      int
+}
+
+func (r *orderRepo) Checkout(ctx context.Context, buyerID int64, lines []domain.OrderLine, clearCart bool) ([]domain.Order, error) {
+	// Схлопываем повторяющиеся товары
+	qty := make(map[int64]int, len(lines))
+	ids := make([]int64, 0, len(lines))
+	for _, l := range lines {
+		if _, ok := qty[l.ProductID]; !ok {
+			ids = append(ids, l.ProductID)
+		}
+		qty[l.ProductID] += l.Quantity
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	var orders []domain.Order
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT id, seller_id, name, price_cents, stock
+			FROM products
+			WHERE id = ANY($1) AND `+visibleSeller+`
+			ORDER BY id
+			FOR UPDATE
+		`, ids)
+		if err != nil {
+			return err
+		}
+		var products []lockedProduct
+		for rows.Next() {
+			var p lockedProduct
+			if err := rows.Scan(&p.id, &p.sellerID, &p.name, &p.priceCents, &p.stock); err != nil {
+				rows.Close()
+				return err
+			}
+			products = append(products, p)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		if len(products) != len(ids) {
+			return ErrProductNotFound
+		}
+
+		// Группируем позиции по продавцам
+		bySeller := map[int64]*domain.Order{}
+		var sellerIDs []int64
+		var total int64
+		for _, p := range products {
+			if p.sellerID == buyerID {
+				return ErrOwnProduct
+			}
+			q := qty[p.id]
+			if q > p.stock {
+				return ErrInsufficientStock
+			}
+			o, ok := bySeller[p.sellerID]
+			if !ok {
+				o = &domain.Order{BuyerID: buyerID, SellerID: p.sellerID, Status: domain.OrderPaid}
+				bySeller[p.sellerID] = o
+				sellerIDs = append(sellerIDs, p.sellerID)
+			}
+			pid := p.id
+			line := p.priceCents * int64(q)
+			o.Items = append(o.Items, domain.OrderItem{
+				ProductID:      &pid,
+				Name:           p.name,
+				PriceCents:     p.priceCents,
+				Quantity:       q,
+				LineTotalCents: line,
+			})
+			o.TotalCents += line
+			total += line
+		}
+
+		wallets, err := lockWallets(ctx, tx, append([]int64{buyerID}, sellerIDs...)...)
+		if err != nil {
+			return err
+		}
+		if err := addBalance(ctx, tx, wallets[buyerID], -total); err != nil {
+			return err
+		}
+
+		sort.Slice(sellerIDs, func(i, j int) bool { return sellerIDs[i] < sellerIDs[j] })
+		for _, sid := range sellerIDs {
+			o := bySeller[sid]
+			if err := addBalance(ctx, tx, wallets[sid], o.TotalCents); err != nil {
+				return err
+			}
+			if err := tx.QueryRow(ctx, `
+				INSERT INTO orders (buyer_id, seller_id, status, total_cents)
+				VALUES ($1, $2, $3, $4)
+				RETURNING id, created_at, updated_at
+			`, o.BuyerID, o.SellerID, o.Status, o.TotalCents).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt); err != nil {
+				return err
+			}
+			if err := insertStatusHistory(ctx, tx, o.ID, "", o.Status, buyerID); err != nil {
+				return err
+			}
+			for _, it := range o.Items {
+				if _, err := tx.Exec(ctx, `
+					INSERT INTO order_items (order_id, product_id, name, price_cents, quantity)
+					VALUES ($1, $2, $3, $4, $5)
+				`, o.ID, it.ProductID, it.Name, it.PriceCents, it.Quantity); err != nil {
+					return err
+				}
+				if _, err := tx.Exec(ctx, `
+					UPDATE products SET stock = stock - $2 WHERE id = $1
+				`, *it.ProductID, it.Quantity); err != nil {
+					return err
+				}
+			}
+			orders = append(orders, *o)
+		}
+
+		if clearCart {
+			if _, err := tx.Exec(ctx, `
+				DELETE FROM cart_items ci
+				USING carts c
+				WHERE ci.cart_id = c.id AND c.user_id = $1 AND ci.product_id = ANY($2)
+			`, buyerID, ids); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *orderRepo) GetByID(ctx context.Context, id int64) (*domain.Order, error) {
+	var o domain.Order
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT id, buyer_id, seller_id, status, total_cents, created_at, updated_at
+		FROM orders WHERE id = $1
+	`, id).Scan(&o.ID, &o.BuyerID, &o.SellerID, &o.Status, &o.TotalCents, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	orders := []domain.Order{o}
+	if err := r.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+func (r *orderRepo) ListByBuyer(ctx context.Context, buyerID int64, limit, offset int32) ([]domain.Order, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, buyer_id, seller_id, status, total_cents, created_at, updated_at
+		FROM orders
+		WHERE buyer_id = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3
+	`, buyerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	return orders, r.loadItems(ctx, orders)
+}
+
+func (r *orderRepo) ListBySeller(ctx context.Context, sellerID int64, status domain.OrderStatus, limit, offset int32) ([]domain.Order, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, buyer_id, seller_id, status, total_cents, created_at, updated_at
+		FROM orders
+		WHERE seller_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4
+	`, sellerID, string(status), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := scanOrders(rows)
+	if err != nil {
+		return nil, err
+	}
+	return orders, r.loadItems(ctx, orders)
+}
+
+func (r *orderRepo) ChangeStatus(ctx context.Context, ch StatusChange) (*domain.Order, error) {
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var o domain.Order
+		err := tx.QueryRow(ctx, `
+			SELECT id, buyer_id, seller_id, status, total_cents
+			FROM orders WHERE id = $1
+			FOR UPDATE
+		`, ch.OrderID).Scan(&o.ID, &o.BuyerID, &o.SellerID, &o.Status, &o.TotalCents)
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return ErrOrderNotFound
+			}
+			return err
+		}
+		if o.Status != ch.From {
+			return ErrOrderStatusConflict
+		}
+		if ch.Restock {
+			if _, err := tx.Exec(ctx, `
+				UPDATE products p
+				SET stock = p.stock + oi.quantity
+				FROM order_items oi
+				WHERE oi.order_id = $1 AND oi.product_id = p.id
+			`, o.ID); err != nil {
+				return err
+			}
+		}
+		if ch.Refund {
+			wallets, err := lockWallets(ctx, tx, o.BuyerID, o.SellerID)
+			if err != nil {
+				return err
+			}
+			if err := addBalance(ctx, tx, wallets[o.SellerID], -o.TotalCents); err != nil {
+				return err
+			}
+			if err := addBalance(ctx, tx, wallets[o.BuyerID], o.TotalCents); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.Exec(ctx, `UPDATE orders SET status = $2 WHERE id = $1`, o.ID, ch.To); err != nil {
+			return err
+		}
+		return insertStatusHistory(ctx, tx, o.ID, ch.From, ch.To, ch.ActorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, ch.OrderID)
+}
+
+func (r *orderRepo) History(ctx context.Context, orderID int64) ([]domain.OrderStatusChange, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT order_id, COALESCE(from_status, ''), to_status, COALESCE(changed_by, 0), created_at
+		FROM order_status_history
+		WHERE order_id = $1
+		ORDER BY id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []domain.OrderStatusChange{}
+	for rows.Next() {
+		var h domain.OrderStatusChange
+		if err := rows.Scan(&h.OrderID, &h.From, &h.To, &h.ChangedBy, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
+
+func insertStatusHistory(ctx context.Context, tx pgx.Tx, orderID int64, from, to domain.OrderStatus, actorID int64) error {
+	var fromStatus *string
+	if from != "" {
+		f := string(from)
+		fromStatus = &f
+	}
+	_, err := tx.Exec(ctx, `
+		INSERT INTO order_status_history (order_id, from_status, to_status, changed_by)
+		VALUES ($1, $2, $3, $4)
+	`, orderID, fromStatus, to, actorID)
+	return err
+}
+
+func scanOrders(rows pgx.Rows) ([]domain.Order, error) {
+	defer rows.Close()
+	orders := []domain.Order{}
+	for rows.Next() {
+		var o domain.Order
+		if err := rows.Scan(&o.ID, &o.BuyerID, &o.SellerID, &o.Status, &o.TotalCents, &o.CreatedAt, &o.UpdatedAt); err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// loadItems подгружает позиции для уже выбранных заказов одним запросом.
+func (r *orderRepo) loadItems(ctx context.Context, orders []domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	idx := make(map[int64]int, len(orders))
+	ids := make([]int64, 0, len(orders))
+	for i, o := range orders {
+		idx[o.ID] = i
+		ids = append(ids, o.ID)
+	}
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT order_id, product_id, name, price_cents, quantity
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY id
+	`, ids)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var orderID int64
+		var it domain.OrderItem
+		if err := rows.Scan(&orderID, &it.ProductID, &it.Name, &it.PriceCents, &it.Quantity); err != nil {
+			return err
+		}
+		it.LineTotalCents = it.PriceCents * int64(it.Quantity)
+		o := &orders[idx[orderID]]
+		o.Items = append(o.Items, it)
+	}
+	return rows.Err()
+}
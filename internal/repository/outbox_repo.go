@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+	"market/internal/events"
+)
+
+type OutboxRepository interface {
+	// Publish кладёт события в outbox; вызывать внутри TxManager.WithinTx вместе с бизнес-записью.
+	Publish(ctx context.Context, evts ...events.Event) error
+	// FetchUnsent блокирует до limit неотправленных сообщений (FOR UPDATE SKIP LOCKED),
+	// поэтому должен вызываться в транзакции — несколько релеев не возьмут одни и те же строки.
+	FetchUnsent(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+	MarkSent(ctx context.Context, ids ...int64) error
+	MarkFailed(ctx context.Context, reason string, ids ...int64) error
+}
+
+type outboxRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewOutboxRepository(pool *pgxpool.Pool) OutboxRepository {
+	return &outboxRepo{pool: pool}
+}
+
+func (r *outboxRepo) Publish(ctx context.Context, evts ...events.Event) error {
+	for _, e := range evts {
+		env, err := events.New(e)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(env)
+		if err != nil {
+			return err
+		}
+		if _, err := conn(ctx, r.pool).Exec(ctx, `
+			INSERT INTO outbox (event_id, event_type, event_version, aggregate_id, payload, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, env.ID, env.Type, env.Version, env.AggregateID, data, env.OccurredAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *outboxRepo) FetchUnsent(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `
+		SELECT id, COALESCE(event_id::text, ''), event_type, event_version, aggregate_id, payload, created_at, attempts
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []domain.OutboxMessage
+	for rows.Next() {
+		var m domain.OutboxMessage
+		if err := rows.Scan(&m.ID, &m.EventID, &m.EventType, &m.EventVersion, &m.AggregateID, &m.Payload, &m.CreatedAt, &m.Attempts); err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+func (r *outboxRepo) MarkSent(ctx context.Context, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE outbox SET sent_at = NOW(), attempts = attempts + 1, last_error = NULL
+		WHERE id = ANY($1)
+	`, ids)
+	return err
+}
+
+func (r *outboxRepo) MarkFailed(ctx context.Context, reason string, ids ...int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE outbox SET attempts = attempts + 1, last_error = $2
+		WHERE id = ANY($1)
+	`, ids, reason)
+	return err
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrResetTokenNotFound = errors.New("reset token not found")
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, t *domain.PasswordResetToken, tokenHash string) error
+	GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id int64) error
+	InvalidateForUser(ctx context.Context, userID int64) error
+}
+
+type passwordResetRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewPasswordResetRepository(pool *pgxpool.Pool) PasswordResetRepository {
+	return &passwordResetRepo{pool: pool}
+}
+
+func (r *passwordResetRepo) Create(ctx context.Context, t *domain.PasswordResetToken, tokenHash string) error {
+	return conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+	`, t.UserID, tokenHash, t.ExpiresAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+const resetTokenSelect = `
+	SELECT id, user_id, expires_at, created_at, used_at
+	FROM password_reset_tokens
+	WHERE token_hash = $1`
+
+func (r *passwordResetRepo) GetByHash(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	return r.get(ctx, resetTokenSelect, tokenHash)
+}
+
+func (r *passwordResetRepo) GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	return r.get(ctx, resetTokenSelect+" FOR UPDATE", tokenHash)
+}
+
+func (r *passwordResetRepo) get(ctx context.Context, query, tokenHash string) (*domain.PasswordResetToken, error) {
+	var t domain.PasswordResetToken
+	err := conn(ctx, r.pool).QueryRow(ctx, query, tokenHash).Scan(&t.ID, &t.UserID, &t.ExpiresAt, &t.CreatedAt, &t.UsedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrResetTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *passwordResetRepo) MarkUsed(ctx context.Context, id int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW() WHERE id = $1
+	`, id)
+	return err
+}
+
+func (r *passwordResetRepo) InvalidateForUser(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`, userID)
+	return err
+}
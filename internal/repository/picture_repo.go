@@ -31,9 +31,9 @@ func NewPictureRepository(pool *pgxpool.Pool) PictureRepository {
 
 func (r *pictureRepo) Create(ctx context.Context, data []byte, mime string) (int64, error) {
 	var id int64
-	_, err := r.pool.Exec(ctx, "SET LOCAL bytea_output='hex'") // no-op safety
+	_, err := conn(ctx, r.pool).Exec(ctx, "SET LOCAL bytea_output='hex'") // no-op safety
 	_ = err
-	err = r.pool.QueryRow(ctx, `
+	err = conn(ctx, r.pool).QueryRow(ctx, `
 		INSERT INTO pictures (data, mime_type, size_bytes)
 		VALUES ($1, $2, $3) RETURNING id
 	`, data, mime, int64(len(data))).Scan(&id)
@@ -42,7 +42,7 @@ func (r *pictureRepo) Create(ctx context.Context, data []byte, mime string) (int
 
 func (r *pictureRepo) AttachAutoPosition(ctx context.Context, productID, pictureID int64) (int, error) {
 	var pos int
-	err := r.pool.QueryRow(ctx, `
+	err := conn(ctx, r.pool).QueryRow(ctx, `
 		WITH next_pos AS (
 		  SELECT COALESCE(MAX(position), 0) + 1 AS pos
 		  FROM product_pictures
@@ -56,7 +56,7 @@ func (r *pictureRepo) AttachAutoPosition(ctx context.Context, productID, picture
 }
 
 func (r *pictureRepo) ListByProduct(ctx context.Context, productID int64) ([]domain.Picture, error) {
-	rows, err := r.pool.Query(ctx, `
+	rows, err := conn(ctx, r.pool).Query(ctx, `
 		SELECT p.id, p.mime_type, p.size_bytes, p.created_at, pp.position
 		FROM product_pictures pp
 		JOIN pictures p ON p.id = pp.picture_id
@@ -82,7 +82,7 @@ func (r *pictureRepo) ListByProduct(ctx context.Context, productID int64) ([]dom
 func (r *pictureRepo) GetData(ctx context.Context, pictureID int64) ([]byte, string, error) {
 	var data []byte
 	var mime string
-	err := r.pool.QueryRow(ctx, `
+	err := conn(ctx, r.pool).QueryRow(ctx, `
 		SELECT data, mime_type FROM pictures WHERE id = $1
 	`, pictureID).Scan(&data, &mime)
 	if err != nil {
@@ -92,7 +92,7 @@ func (r *pictureRepo) GetData(ctx context.Context, pictureID int64) ([]byte, str
 }
 
 func (r *pictureRepo) Detach(ctx context.Context, productID, pictureID int64) error {
-	ct, err := r.pool.Exec(ctx, `
+	ct, err := conn(ctx, r.pool).Exec(ctx, `
 		DELETE FROM product_pictures WHERE product_id = $1 AND picture_id = $2
 	`, productID, pictureID)
 	if err != nil {
@@ -102,7 +102,7 @@ func (r *pictureRepo) Detach(ctx context.Context, productID, pictureID int64) er
 		return ErrNotAttached
 	}
 	// если удалили обложку — обнулим её
-	_, _ = r.pool.Exec(ctx, `
+	_, _ = conn(ctx, r.pool).Exec(ctx, `
 		UPDATE products SET cover_picture_id = NULL
 		WHERE id = $1 AND cover_picture_id = $2
 	`, productID, pictureID)
@@ -110,7 +110,7 @@ func (r *pictureRepo) Detach(ctx context.Context, productID, pictureID int64) er
 }
 
 func (r *pictureRepo) DeletePicture(ctx context.Context, pictureID int64) error {
-	ct, err := r.pool.Exec(ctx, `DELETE FROM pictures WHERE id = $1`, pictureID)
+	ct, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM pictures WHERE id = $1`, pictureID)
 	if err != nil {
 		return err
 	}
@@ -121,7 +121,7 @@ func (r *pictureRepo) DeletePicture(ctx context.Context, pictureID int64) error
 }
 
 func (r *pictureRepo) SetCoverIfAttached(ctx context.Context, productID, pictureID int64) error {
-	ct, err := r.pool.Exec(ctx, `
+	ct, err := conn(ctx, r.pool).Exec(ctx, `
 		UPDATE products p
 		SET cover_picture_id = $2
 		WHERE p.id = $1
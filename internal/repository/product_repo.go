@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -13,16 +14,24 @@ import (
 var ErrProductNotFound = errors.New("product not found")
 
 type ProductFilter struct {
-	Limit  int32
-	Offset int32
-	Query  string // optional name search
+	Limit    int32
+	Offset   int32
+	Query    string // optional name search
+	SellerID int64  // 0 — товары всех продавцов
+	// IncludeHidden — вместе с товарами заблокированных продавцов (для персонала)
+	IncludeHidden bool
 }
 
 type ProductRepository interface {
 	Create(ctx context.Context, p *domain.Product) (int64, error)
+	// GetByID — публичное чтение: товары заблокированных продавцов не находятся.
 	GetByID(ctx context.Context, id int64) (*domain.Product, error)
+	// GetByIDIncludingHidden находит и скрытые товары — для изменения владельцем или персоналом.
+	GetByIDIncludingHidden(ctx context.Context, id int64) (*domain.Product, error)
 	Update(ctx context.Context, p *domain.Product) error
 	Delete(ctx context.Context, id int64) error
+	// DeleteBySeller удаляет все товары продавца и возвращает их id.
+	DeleteBySeller(ctx context.Context, sellerID int64) ([]int64, error)
 	List(ctx context.Context, f ProductFilter) ([]domain.Product, error)
 }
 
@@ -36,7 +45,7 @@ func NewProductRepository(pool *pgxpool.Pool) ProductRepository {
 
 func (r *productRepo) Create(ctx context.Context, p *domain.Product) (int64, error) {
 	var id int64
-	err := r.pool.QueryRow(ctx, `
+	err := conn(ctx, r.pool).QueryRow(ctx, `
 		INSERT INTO products (seller_id, name, description, price_cents, stock, cover_picture_id)
 		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING id, created_at, updated_at, cover_picture_id
@@ -45,11 +54,31 @@ func (r *productRepo) Create(ctx context.Context, p *domain.Product) (int64, err
 	return id, err
 }
 
+// visibleSeller отсекает товары заблокированных продавцов
+var visibleSeller = visibleSellerOf("products")
+
+// visibleSellerOf — то же для запросов, где таблица products идёт под псевдонимом.
+func visibleSellerOf(products string) string {
+	return `NOT EXISTS (SELECT 1 FROM users u WHERE u.id = ` + products + `.seller_id AND u.suspended_at IS NOT NULL)`
+}
+
 func (r *productRepo) GetByID(ctx context.Context, id int64) (*domain.Product, error) {
-	row := r.pool.QueryRow(ctx, `
+	return r.getByID(ctx, id, false)
+}
+
+func (r *productRepo) GetByIDIncludingHidden(ctx context.Context, id int64) (*domain.Product, error) {
+	return r.getByID(ctx, id, true)
+}
+
+func (r *productRepo) getByID(ctx context.Context, id int64, includeHidden bool) (*domain.Product, error) {
+	q := `
 		SELECT id, seller_id, name, COALESCE(description, ''), price_cents, stock, cover_picture_id, created_at, updated_at
 		FROM products WHERE id = $1
-	`, id)
+	`
+	if !includeHidden {
+		q += " AND " + visibleSeller
+	}
+	row := conn(ctx, r.pool).QueryRow(ctx, q, id)
 	var p domain.Product
 	if err := row.Scan(&p.ID, &p.SellerID, &p.Name, &p.Description, &p.PriceCents, &p.Stock, &p.CoverPictureID, &p.CreatedAt, &p.UpdatedAt); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -61,7 +90,7 @@ func (r *productRepo) GetByID(ctx context.Context, id int64) (*domain.Product, e
 }
 
 func (r *productRepo) Update(ctx context.Context, p *domain.Product) error {
-	return r.pool.QueryRow(ctx, `
+	return conn(ctx, r.pool).QueryRow(ctx, `
 		UPDATE products
 		SET name = $2,
 		    description = $3,
@@ -76,7 +105,7 @@ func (r *productRepo) Update(ctx context.Context, p *domain.Product) error {
 }
 
 func (r *productRepo) Delete(ctx context.Context, id int64) error {
-	ct, err := r.pool.Exec(ctx, `DELETE FROM products WHERE id = $1`, id)
+	ct, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM products WHERE id = $1`, id)
 	if err != nil {
 		return err
 	}
@@ -86,6 +115,24 @@ func (r *productRepo) Delete(ctx context.Context, id int64) error {
 	return nil
 }
 
+func (r *productRepo) DeleteBySeller(ctx context.Context, sellerID int64) ([]int64, error) {
+	rows, err := conn(ctx, r.pool).Query(ctx, `DELETE FROM products WHERE seller_id = $1 RETURNING id`, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 func (r *productRepo) List(ctx context.Context, f ProductFilter) ([]domain.Product, error) {
 	q := `
 		SELECT id, seller_id, name, COALESCE(description, ''), price_cents, stock, cover_picture_id, created_at, updated_at
@@ -93,11 +140,23 @@ func (r *productRepo) List(ctx context.Context, f ProductFilter) ([]domain.Produ
 	`
 	args := []any{}
 	idx := 1
+	var where []string
 	if f.Query != "" {
-		q += fmt.Sprintf(" WHERE lower(name) LIKE lower($%d)", idx)
+		where = append(where, fmt.Sprintf("lower(name) LIKE lower($%d)", idx))
 		args = append(args, "%"+f.Query+"%")
 		idx++
 	}
+	if f.SellerID != 0 {
+		where = append(where, fmt.Sprintf("seller_id = $%d", idx))
+		args = append(args, f.SellerID)
+		idx++
+	}
+	if !f.IncludeHidden {
+		where = append(where, visibleSeller)
+	}
+	if len(where) > 0 {
+		q += " WHERE " + strings.Join(where, " AND ")
+	}
 	q += " ORDER BY id DESC"
 	if f.Limit <= 0 {
 		f.Limit = 50
@@ -105,7 +164,7 @@ func (r *productRepo) List(ctx context.Context, f ProductFilter) ([]domain.Produ
 	q += fmt.Sprintf(" LIMIT $%d OFFSET $%d", idx, idx+1)
 	args = append(args, f.Limit, f.Offset)
 
-	rows, err := r.pool.Query(ctx, q, args...)
+	rows, err := conn(ctx, r.pool).Query(ctx, q, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, t *domain.RefreshToken, tokenHash string) error
+	// GetByHashForUpdate блокирует строку токена, чтобы параллельные ротации не прошли обе.
+	GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	Revoke(ctx context.Context, id int64, replacedBy *int64) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeAllForUser(ctx context.Context, userID int64) error
+}
+
+type refreshTokenRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewRefreshTokenRepository(pool *pgxpool.Pool) RefreshTokenRepository {
+	return &refreshTokenRepo{pool: pool}
+}
+
+func (r *refreshTokenRepo) Create(ctx context.Context, t *domain.RefreshToken, tokenHash string) error {
+	return conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, authenticated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, t.UserID, t.FamilyID, tokenHash, t.ExpiresAt, t.AuthenticatedAt).Scan(&t.ID, &t.CreatedAt)
+}
+
+func (r *refreshTokenRepo) GetByHashForUpdate(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var t domain.RefreshToken
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT id, user_id, family_id::text, expires_at, created_at, revoked_at, replaced_by, authenticated_at
+		FROM refresh_tokens
+		WHERE token_hash = $1
+		FOR UPDATE
+	`, tokenHash).Scan(&t.ID, &t.UserID, &t.FamilyID, &t.ExpiresAt, &t.CreatedAt, &t.RevokedAt, &t.ReplacedBy, &t.AuthenticatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *refreshTokenRepo) Revoke(ctx context.Context, id int64, replacedBy *int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE refresh_tokens
+		SET revoked_at = COALESCE(revoked_at, NOW()), replaced_by = COALESCE($2, replaced_by)
+		WHERE id = $1
+	`, id, replacedBy)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeFamily(ctx context.Context, familyID string) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE family_id = $1 AND revoked_at IS NULL
+	`, familyID)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeAllForUser(ctx context.Context, userID int64) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE refresh_tokens SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	return err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TokenStatus — состояние отзыва для access-токена: его jti, водяной знак и блокировка владельца.
+type TokenStatus struct {
+	Revoked   bool // jti в денылисте
+	Suspended bool // владелец заблокирован
+	// ValidAfter — водяной знак пользователя: токены, выпущенные не позже, недействительны
+	ValidAfter *time.Time
+}
+
+type RevocationRepository interface {
+	RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	// TokenStatus одним запросом читает денылист и водяной знак пользователя.
+	TokenStatus(ctx context.Context, userID int64, jti string) (*TokenStatus, error)
+	BumpTokensValidAfter(ctx context.Context, userID int64, at time.Time) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type revocationRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewRevocationRepository(pool *pgxpool.Pool) RevocationRepository {
+	return &revocationRepo{pool: pool}
+}
+
+func (r *revocationRepo) RevokeToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	_, err := conn(ctx, r.pool).Exec(ctx, `
+		INSERT INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING
+	`, jti, userID, expiresAt)
+	return err
+}
+
+func (r *revocationRepo) TokenStatus(ctx context.Context, userID int64, jti string) (*TokenStatus, error) {
+	var st TokenStatus
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $2), suspended_at IS NOT NULL, tokens_valid_after
+		FROM users WHERE id = $1
+	`, userID, jti).Scan(&st.Revoked, &st.Suspended, &st.ValidAfter)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (r *revocationRepo) BumpTokensValidAfter(ctx context.Context, userID int64, at time.Time) error {
+	ct, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET tokens_valid_after = GREATEST(tokens_valid_after, $2)
+		WHERE id = $1
+	`, userID, at)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *revocationRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `DELETE FROM revoked_tokens WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
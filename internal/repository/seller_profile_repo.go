@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"market/internal/domain"
+)
+
+var ErrSellerProfileNotFound = errors.New("seller profile not found")
+var ErrSlugTaken = errors.New("slug already taken")
+
+type SellerProfileRepository interface {
+	// GetBySlug не находит профили удалённых и заблокированных пользователей.
+	GetBySlug(ctx context.Context, slug string) (*domain.SellerProfile, error)
+	GetByUserID(ctx context.Context, userID int64) (*domain.SellerProfile, error)
+	// Upsert создаёт или обновляет профиль; логотип не трогает.
+	Upsert(ctx context.Context, p *domain.SellerProfile) error
+	// SetLogo меняет логотип и возвращает прежний.
+	SetLogo(ctx context.Context, userID int64, pictureID *int64) (*int64, error)
+	// Delete удаляет профиль и возвращает его логотип.
+	Delete(ctx context.Context, userID int64) (*int64, error)
+}
+
+type sellerProfileRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewSellerProfileRepository(pool *pgxpool.Pool) SellerProfileRepository {
+	return &sellerProfileRepo{pool: pool}
+}
+
+const sellerProfileColumns = `sp.user_id, sp.slug, sp.shop_name, sp.description, sp.logo_picture_id,
+	sp.contact_email, sp.contact_phone, sp.created_at, sp.updated_at`
+
+func scanSellerProfile(row pgx.Row) (*domain.SellerProfile, error) {
+	var p domain.SellerProfile
+	err := row.Scan(&p.UserID, &p.Slug, &p.ShopName, &p.Description, &p.LogoPictureID,
+		&p.ContactEmail, &p.ContactPhone, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrSellerProfileNotFound
+		}
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *sellerProfileRepo) GetBySlug(ctx context.Context, slug string) (*domain.SellerProfile, error) {
+	return scanSellerProfile(conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT `+sellerProfileColumns+`
+		FROM seller_profiles sp
+		JOIN users u ON u.id = sp.user_id
+		WHERE sp.slug = $1 AND u.deleted_at IS NULL AND u.suspended_at IS NULL
+	`, slug))
+}
+
+func (r *sellerProfileRepo) GetByUserID(ctx context.Context, userID int64) (*domain.SellerProfile, error) {
+	return scanSellerProfile(conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT `+sellerProfileColumns+`
+		FROM seller_profiles sp
+		WHERE sp.user_id = $1
+	`, userID))
+}
+
+func (r *sellerProfileRepo) Upsert(ctx context.Context, p *domain.SellerProfile) error {
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		INSERT INTO seller_profiles (user_id, slug, shop_name, description, contact_email, contact_phone)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id) DO UPDATE SET
+			slug = EXCLUDED.slug,
+			shop_name = EXCLUDED.shop_name,
+			description = EXCLUDED.description,
+			contact_email = EXCLUDED.contact_email,
+			contact_phone = EXCLUDED.contact_phone,
+			updated_at = NOW()
+		RETURNING logo_picture_id, created_at, updated_at
+	`, p.UserID, p.Slug, p.ShopName, p.Description, p.ContactEmail, p.ContactPhone).
+		Scan(&p.LogoPictureID, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrSlugTaken
+	}
+	return err
+}
+
+func (r *sellerProfileRepo) SetLogo(ctx context.Context, userID int64, pictureID *int64) (*int64, error) {
+	var prev *int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		UPDATE seller_profiles sp SET logo_picture_id = $2, updated_at = NOW()
+		FROM (SELECT user_id, logo_picture_id FROM seller_profiles WHERE user_id = $1 FOR UPDATE) old
+		WHERE sp.user_id = old.user_id
+		RETURNING old.logo_picture_id
+	`, userID, pictureID).Scan(&prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSellerProfileNotFound
+	}
+	return prev, err
+}
+
+func (r *sellerProfileRepo) Delete(ctx context.Context, userID int64) (*int64, error) {
+	var logo *int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		DELETE FROM seller_profiles WHERE user_id = $1 RETURNING logo_picture_id
+	`, userID).Scan(&logo)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSellerProfileNotFound
+	}
+	return logo, err
+}
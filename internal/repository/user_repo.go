@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -10,11 +11,37 @@ import (
 )
 
 var ErrUserNotFound = errors.New("user not found")
+var ErrEmailTaken = errors.New("email already in use")
+
+// UserFilter — выборка пользователей для персонала. Удалённые не попадают.
+type UserFilter struct {
+	Limit     int32
+	Offset    int32
+	Query     string      // подстрока email или имени
+	Role      domain.Role // "" — любая
+	Suspended *bool       // nil — все
+}
 
 type UserRepository interface {
 	Create(ctx context.Context, email, passwordHash string, role domain.Role) (int64, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
 	GetByID(ctx context.Context, id int64) (*domain.User, error)
+	UpdatePassword(ctx context.Context, id int64, passwordHash string) error
+	// MarkEmailVerified подтверждает адрес, только если он всё ещё совпадает с email пользователя.
+	// Если email совпадает с pending_email, он становится основным адресом.
+	MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error)
+	// UpdateProfile записывает имя и телефон; nil очищает поле.
+	UpdateProfile(ctx context.Context, id int64, displayName, phone *string) error
+	// SetAvatar меняет аватар и возвращает прежний, чтобы его можно было удалить.
+	SetAvatar(ctx context.Context, id int64, pictureID *int64) (*int64, error)
+	SetPendingEmail(ctx context.Context, id int64, email string) error
+	// SoftDelete помечает пользователя удалённым и стирает персональные данные.
+	// Возвращает прежний аватар.
+	SoftDelete(ctx context.Context, id int64) (*int64, error)
+	List(ctx context.Context, f UserFilter) ([]domain.User, error)
+	Suspend(ctx context.Context, id, by int64, reason string) error
+	Unsuspend(ctx context.Context, id int64) error
+	SetRole(ctx context.Context, id int64, role domain.Role) error
 }
 
 type userRepo struct {
@@ -27,21 +54,26 @@ func NewUserRepository(pool *pgxpool.Pool) UserRepository {
 
 func (r *userRepo) Create(ctx context.Context, email, passwordHash string, role domain.Role) (int64, error) {
 	var id int64
-	err := r.pool.QueryRow(ctx, `
+	err := conn(ctx, r.pool).QueryRow(ctx, `
 		INSERT INTO users (email, password_hash, role)
 		VALUES ($1, $2, $3) RETURNING id
 	`, email, passwordHash, role).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrEmailTaken
+	}
 	return id, err
 }
 
-func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, email, password_hash, role, created_at
-		FROM users
-		WHERE email = $1
-	`, email)
+const userColumns = `id, email, password_hash, role, created_at, email_verified_at,
+	display_name, phone, avatar_picture_id, pending_email, deleted_at,
+	suspended_at, suspension_reason, suspended_by`
+
+func scanUser(row pgx.Row) (*domain.User, error) {
 	var u domain.User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt); err != nil {
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.EmailVerifiedAt,
+		&u.DisplayName, &u.Phone, &u.AvatarPictureID, &u.PendingEmail, &u.DeletedAt,
+		&u.SuspendedAt, &u.SuspensionReason, &u.SuspendedBy)
+	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
 		}
@@ -50,18 +82,201 @@ func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User,
 	return &u, nil
 }
 
+// Удалённые пользователи не находятся ни по email, ни по id
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	return scanUser(conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE email = $1 AND deleted_at IS NULL
+	`, email))
+}
+
 func (r *userRepo) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	row := r.pool.QueryRow(ctx, `
-		SELECT id, email, password_hash, role, created_at
+	return scanUser(conn(ctx, r.pool).QueryRow(ctx, `
+		SELECT `+userColumns+`
 		FROM users
-		WHERE id = $1
-	`, id)
-	var u domain.User
-	if err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.CreatedAt); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return nil, ErrUserNotFound
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id))
+}
+
+func (r *userRepo) UpdatePassword(ctx context.Context, id int64, passwordHash string) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET password_hash = $2 WHERE id = $1
+	`, id, passwordHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) MarkEmailVerified(ctx context.Context, id int64, email string) (bool, error) {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET
+			email_verified_at = CASE WHEN email = $2 THEN COALESCE(email_verified_at, NOW()) ELSE NOW() END,
+			email = $2,
+			pending_email = NULL
+		WHERE id = $1 AND deleted_at IS NULL AND (email = $2 OR pending_email = $2)
+	`, id, email)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, ErrEmailTaken
+		}
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+func (r *userRepo) UpdateProfile(ctx context.Context, id int64, displayName, phone *string) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET display_name = $2, phone = $3
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, displayName, phone)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) SetAvatar(ctx context.Context, id int64, pictureID *int64) (*int64, error) {
+	var prev *int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		UPDATE users u SET avatar_picture_id = $2
+		FROM (SELECT id, avatar_picture_id FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id AND u.deleted_at IS NULL
+		RETURNING old.avatar_picture_id
+	`, id, pictureID).Scan(&prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return prev, err
+}
+
+func (r *userRepo) SetPendingEmail(ctx context.Context, id int64, email string) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET pending_email = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, email)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) SoftDelete(ctx context.Context, id int64) (*int64, error) {
+	// Адрес заменяется заглушкой: уникальность email сохраняется, а сам адрес
+	// освобождается для новой регистрации
+	var prev *int64
+	err := conn(ctx, r.pool).QueryRow(ctx, `
+		UPDATE users u SET
+			email = 'deleted-' || u.id || '@deleted.invalid',
+			password_hash = '',
+			email_verified_at = NULL,
+			display_name = NULL,
+			phone = NULL,
+			avatar_picture_id = NULL,
+			pending_email = NULL,
+			deleted_at = NOW()
+		FROM (SELECT id, avatar_picture_id FROM users WHERE id = $1 FOR UPDATE) old
+		WHERE u.id = old.id AND u.deleted_at IS NULL
+		RETURNING old.avatar_picture_id
+	`, id).Scan(&prev)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrUserNotFound
+	}
+	return prev, err
+}
+
+func (r *userRepo) List(ctx context.Context, f UserFilter) ([]domain.User, error) {
+	q := `SELECT ` + userColumns + ` FROM users WHERE deleted_at IS NULL`
+	args := []any{}
+	idx := 1
+	if f.Query != "" {
+		q += fmt.Sprintf(" AND (email ILIKE $%d OR display_name ILIKE $%d)", idx, idx)
+		args = append(args, "%"+f.Query+"%")
+		idx++
+	}
+	if f.Role != "" {
+		q += fmt.Sprintf(" AND role = $%d", idx)
+		args = append(args, f.Role)
+		idx++
+	}
+	if f.Suspended != nil {
+		if *f.Suspended {
+			q += " AND suspended_at IS NOT NULL"
+		} else {
+			q += " AND suspended_at IS NULL"
 		}
+	}
+	q += " ORDER BY id DESC"
+	if f.Limit <= 0 {
+		f.Limit = 50
+	}
+	q += fmt.Sprintf(" LIMIT $%d OFFSET $%d", idx, idx+1)
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := conn(ctx, r.pool).Query(ctx, q, args...)
+	if err != nil {
 		return nil, err
 	}
-	return &u, nil
+	defer rows.Close()
+
+	out := []domain.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *u)
+	}
+	return out, rows.Err()
+}
+
+func (r *userRepo) Suspend(ctx context.Context, id, by int64, reason string) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET suspended_at = NOW(), suspension_reason = $3, suspended_by = $2
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id, by, reason)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) Unsuspend(ctx context.Context, id int64) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET suspended_at = NULL, suspension_reason = NULL, suspended_by = NULL
+		WHERE id = $1 AND deleted_at IS NULL
+	`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+func (r *userRepo) SetRole(ctx context.Context, id int64, role domain.Role) error {
+	tag, err := conn(ctx, r.pool).Exec(ctx, `
+		UPDATE users SET role = $2 WHERE id = $1 AND deleted_at IS NULL
+	`, id, role)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
 }
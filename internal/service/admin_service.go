@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/repository"
+)
+
+var ErrSuspensionReasonRequired = errors.New("suspension reason required (up to 500 characters)")
+var ErrInvalidRole = errors.New("invalid role")
+var ErrSelfAction = errors.New("cannot apply this action to yourself")
+var ErrStaffTarget = errors.New("only admins can manage staff accounts")
+
+const maxSuspensionReasonLen = 500
+
+type AdminService struct {
+	users         repository.UserRepository
+	products      repository.ProductRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocation    *RevocationService
+	tx            repository.TxManager
+	publisher     EventPublisher
+	audit         *Auditor
+}
+
+func NewAdminService(users repository.UserRepository, products repository.ProductRepository, refreshTokens repository.RefreshTokenRepository, revocation *RevocationService, tx repository.TxManager, publisher EventPublisher, audit *Auditor) *AdminService {
+	return &AdminService{users: users, products: products, refreshTokens: refreshTokens, revocation: revocation, tx: tx, publisher: publisher, audit: audit}
+}
+
+// AdminUserView — пользователь вместе с его товарами, включая скрытые блокировкой.
+type AdminUserView struct {
+	domain.User
+	Products []domain.Product `json:"products"`
+}
+
+func (s *AdminService) ListUsers(ctx context.Context, f repository.UserFilter) ([]domain.User, error) {
+	return s.users.List(ctx, f)
+}
+
+func (s *AdminService) GetUser(ctx context.Context, id int64) (*AdminUserView, error) {
+	u, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.products.List(ctx, repository.ProductFilter{
+		Limit:         100,
+		SellerID:      id,
+		IncludeHidden: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if products == nil {
+		products = []domain.Product{}
+	}
+	return &AdminUserView{User: *u, Products: products}, nil
+}
+
+// Suspend блокирует пользователя: вход, refresh и API-ключи перестают работать,
+// выданные access-токены отзываются, товары пропадают из публичной выдачи.
+func (s *AdminService) Suspend(ctx context.Context, actor domain.Actor, id int64, reason string) error {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || utf8.RuneCountInString(reason) > maxSuspensionReasonLen {
+		return ErrSuspensionReasonRequired
+	}
+	if _, err := s.target(ctx, actor, id); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.Suspend(ctx, id, actor.UserID, reason); err != nil {
+			return err
+		}
+		if err := s.endSessions(ctx, id); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserSuspend, EntityType: "user", EntityID: id,
+			After: map[string]string{"suspension_reason": reason},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserSuspended{UserID: id, Reason: reason, By: actor.UserID})
+	})
+}
+
+func (s *AdminService) Unsuspend(ctx context.Context, actor domain.Actor, id int64) error {
+	u, err := s.target(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.Unsuspend(ctx, id); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserUnsuspend, EntityType: "user", EntityID: id,
+			Before: map[string]*string{"suspension_reason": u.SuspensionReason},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserUnsuspended{UserID: id, By: actor.UserID})
+	})
+}
+
+// SetRole меняет роль. Выданные access-токены со старой ролью отзываются,
+// новая роль приходит с ближайшим /auth/refresh.
+func (s *AdminService) SetRole(ctx context.Context, actor domain.Actor, id int64, role domain.Role) error {
+	if !role.Valid() {
+		return ErrInvalidRole
+	}
+	u, err := s.target(ctx, actor, id)
+	if err != nil {
+		return err
+	}
+	if u.Role == role {
+		return nil
+	}
+	return s.changeRole(ctx, u, role, actor.UserID)
+}
+
+// BootstrapAdmin назначает администратором существующего пользователя с email;
+// вызывается при старте для адресов из auth.bootstrapAdmins. Так появляется первый
+// администратор, который дальше раздаёт роли через API. Возвращает false, если
+// пользователь уже администратор.
+func (s *AdminService) BootstrapAdmin(ctx context.Context, email string) (bool, error) {
+	u, err := s.users.GetByEmail(ctx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil {
+		return false, err
+	}
+	if u.Role == domain.RoleAdmin {
+		return false, nil
+	}
+	return true, s.changeRole(ctx, u, domain.RoleAdmin, 0)
+}
+
+// changeRole меняет роль u на role; by — кто меняет (0 — система).
+func (s *AdminService) changeRole(ctx context.Context, u *domain.User, role domain.Role, by int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.SetRole(ctx, u.ID, role); err != nil {
+			return err
+		}
+		if err := s.revocation.RevokeAllForUser(ctx, u.ID); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserRoleChange, EntityType: "user", EntityID: u.ID,
+			Before: map[string]domain.Role{"role": u.Role},
+			After:  map[string]domain.Role{"role": role},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserRoleChanged{UserID: u.ID, Role: role, PreviousRole: u.Role, By: by})
+	})
+}
+
+// target загружает пользователя, над которым выполняется действие. Себя менять
+// нельзя, чтобы случайно не потерять доступ; модераторов и админов меняют только админы.
+func (s *AdminService) target(ctx context.Context, actor domain.Actor, id int64) (*domain.User, error) {
+	if id == actor.UserID {
+		return nil, ErrSelfAction
+	}
+	u, err := s.users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role.Staff() && actor.Role != domain.RoleAdmin {
+		return nil, ErrStaffTarget
+	}
+	return u, nil
+}
+
+func (s *AdminService) endSessions(ctx context.Context, userID int64) error {
+	if err := s.refreshTokens.RevokeAllForUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.revocation.RevokeAllForUser(ctx, userID)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"market/internal/domain"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrAPIKeyLimit = errors.New("api key limit reached")
+var ErrInvalidAPIKeyName = errors.New("api key name must be 1-100 characters")
+var ErrInvalidExpiry = errors.New("expires_at must be in the future")
+
+const (
+	maxAPIKeysPerUser = 20
+	maxAPIKeyNameLen  = 100
+)
+
+type APIKeyService struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+	tx    repository.TxManager
+	audit *Auditor
+}
+
+func NewAPIKeyService(keys repository.APIKeyRepository, users repository.UserRepository, tx repository.TxManager, audit *Auditor) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users, tx: tx, audit: audit}
+}
+
+type APIKeyCreateInput struct {
+	Name      string              `json:"name"`
+	Scopes    []domain.Permission `json:"scopes"`
+	ExpiresAt *time.Time          `json:"expires_at"`
+}
+
+// APIKeyCreated — созданный ключ вместе с секретом; секрет больше нигде не показывается.
+type APIKeyCreated struct {
+	domain.APIKey
+	Key string `json:"key"`
+}
+
+// Create выпускает ключ. Области ключа — подмножество прав роли владельца.
+func (s *APIKeyService) Create(ctx context.Context, actor domain.Actor, in APIKeyCreateInput) (*APIKeyCreated, error) {
+	name := strings.TrimSpace(in.Name)
+	if n := utf8.RuneCountInString(name); n == 0 || n > maxAPIKeyNameLen {
+		return nil, ErrInvalidAPIKeyName
+	}
+	if len(in.Scopes) == 0 {
+		return nil, errors.New("at least one scope required")
+	}
+	for _, p := range in.Scopes {
+		if !actor.Can(p) {
+			return nil, fmt.Errorf("scope not allowed for your role: %s", p)
+		}
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+	n, err := s.keys.CountByUser(ctx, actor.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxAPIKeysPerUser {
+		return nil, ErrAPIKeyLimit
+	}
+
+	plain, prefix, hash, err := utils.NewAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	k := domain.APIKey{
+		UserID:    actor.UserID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    in.Scopes,
+		ExpiresAt: in.ExpiresAt,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.keys.Create(ctx, &k, hash); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditRecord{
+			Action: AuditAPIKeyCreate, EntityType: "api_key", EntityID: k.ID,
+			After: map[string]any{"name": k.Name, "prefix": k.Prefix, "scopes": k.Scopes, "expires_at": k.ExpiresAt},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &APIKeyCreated{APIKey: k, Key: plain}, nil
+}
+
+func (s *APIKeyService) List(ctx context.Context, userID int64) ([]domain.APIKey, error) {
+	return s.keys.ListByUser(ctx, userID)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.keys.Delete(ctx, userID, id); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditRecord{Action: AuditAPIKeyRevoke, EntityType: "api_key", EntityID: id})
+	})
+}
+
+// Authenticate проверяет ключ из X-API-Key; nil без ошибки — ключ недействителен.
+// Роль берётся у владельца на момент запроса, так что понижение роли сразу
+// урезает и права ключа.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*domain.Actor, error) {
+	if !strings.HasPrefix(key, utils.APIKeyPrefix) {
+		return nil, nil
+	}
+	k, err := s.keys.GetByHash(ctx, utils.HashToken(key))
+	if err != nil {
+		if errors.Is(err, repository.ErrAPIKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return nil, nil
+	}
+	u, err := s.users.GetByID(ctx, k.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if u.SuspendedAt != nil {
+		return nil, nil
+	}
+	if err := s.keys.TouchLastUsed(ctx, k.ID); err != nil {
+		return nil, err
+	}
+	return &domain.Actor{UserID: u.ID, Role: u.Role, Scopes: k.Scopes}, nil
+}
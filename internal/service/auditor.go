@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+
+	"market/internal/domain"
+	"market/internal/repository"
+)
+
+// Действия журнала аудита: <сущность>.<действие>
+const (
+	AuditUserRegister       = "user.register"
+	AuditUserLogin          = "user.login"
+	AuditUserLoginFailed    = "user.login_failed"
+	AuditUserLogout         = "user.logout"
+	AuditUserPasswordChange = "user.password_change"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditUserEmailChange    = "user.email_change"
+	AuditUserDelete         = "user.delete"
+	AuditUserProfileUpdate  = "user.profile_update"
+	AuditUserMFAEnable      = "user.mfa_enable"
+	AuditUserMFADisable     = "user.mfa_disable"
+	AuditUserRecoveryCodes  = "user.mfa_recovery_codes"
+	AuditUserSuspend        = "user.suspend"
+	AuditUserUnsuspend      = "user.unsuspend"
+	AuditUserRoleChange     = "user.role_change"
+	AuditAPIKeyCreate       = "api_key.create"
+	AuditAPIKeyRevoke       = "api_key.revoke"
+	AuditProductCreate      = "product.create"
+	AuditProductUpdate      = "product.update"
+	AuditProductDelete      = "product.delete"
+	AuditProductCover       = "product.cover_change"
+	AuditPictureAttach      = "picture.attach"
+	AuditPictureDetach      = "picture.detach"
+	AuditPictureDelete      = "picture.delete"
+)
+
+// Поля, которые меняются при любом изменении и только засоряют diff
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// Auditor пишет журнал аудита. Кто и откуда действует, берётся из domain.RequestMeta
+// в ctx. Вызванный внутри WithinTx, пишет в той же транзакции, что и само изменение.
+type Auditor struct {
+	repo repository.AuditRepository
+}
+
+func NewAuditor(repo repository.AuditRepository) *Auditor {
+	return &Auditor{repo: repo}
+}
+
+type AuditRecord struct {
+	Action     string
+	EntityType string
+	EntityID   int64
+	// Before и After сериализуются в JSON; в журнал попадают только отличающиеся поля.
+	// nil — сущности не было (создание) или не стало (удаление).
+	Before any
+	After  any
+	// ActorID задаёт автора явно, когда в ctx его ещё нет (например, при входе)
+	ActorID int64
+}
+
+func (a *Auditor) Record(ctx context.Context, rec AuditRecord) error {
+	meta := domain.RequestMetaFrom(ctx)
+	e := &domain.AuditEntry{
+		ActorRole:  meta.ActorRole,
+		Action:     rec.Action,
+		EntityType: rec.EntityType,
+		IP:         meta.IP,
+		RequestID:  meta.RequestID,
+	}
+	actorID := meta.ActorID
+	if rec.ActorID != 0 {
+		actorID = rec.ActorID
+	}
+	if actorID != 0 {
+		e.ActorID = &actorID
+	}
+	if rec.EntityID != 0 {
+		e.EntityID = strconv.FormatInt(rec.EntityID, 10)
+	}
+	diff, err := auditDiff(rec.Before, rec.After)
+	if err != nil {
+		return err
+	}
+	e.Diff = diff
+	return a.repo.Insert(ctx, e)
+}
+
+func (a *Auditor) List(ctx context.Context, f repository.AuditFilter) ([]domain.AuditEntry, error) {
+	return a.repo.List(ctx, f)
+}
+
+type auditChange struct {
+	Before any `json:"before,omitempty"`
+	After  any `json:"after,omitempty"`
+}
+
+// auditDiff сравнивает JSON-представления before и after по полям верхнего уровня.
+func auditDiff(before, after any) (json.RawMessage, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+	changes := map[string]auditChange{}
+	compare := func(k string) {
+		if auditIgnoredFields[k] || reflect.DeepEqual(b[k], a[k]) {
+			return
+		}
+		changes[k] = auditChange{Before: b[k], After: a[k]}
+	}
+	for k := range b {
+		compare(k)
+	}
+	for k := range a {
+		compare(k)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+func auditFields(v any) (map[string]any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type product struct {
+		Title     string    `json:"title"`
+		Price     int64     `json:"price_cents"`
+		Tags      []string  `json:"tags"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	old := product{Title: "Phone", Price: 99900, Tags: []string{"a"}, UpdatedAt: time.Unix(1, 0)}
+	changed := old
+	changed.Price = 89900
+	changed.UpdatedAt = time.Unix(2, 0)
+	retagged := old
+	retagged.Tags = []string{"a", "b"}
+	email := "new@example.com"
+
+	tests := []struct {
+		name          string
+		before, after any
+		want          string // пусто — diff нет
+	}{
+		{"изменилось одно поле", old, changed, `{"price_cents":{"before":99900,"after":89900}}`},
+		{"только updated_at", old, product{Title: "Phone", Price: 99900, Tags: []string{"a"}, UpdatedAt: time.Unix(5, 0)}, ""},
+		{"без изменений", old, old, ""},
+		{"вложенное значение", old, retagged, `{"tags":{"before":["a"],"after":["a","b"]}}`},
+		{"создание", nil, map[string]string{"name": "ci"}, `{"name":{"after":"ci"}}`},
+		{"удаление", map[string]int{"stock": 3}, nil, `{"stock":{"before":3}}`},
+		{"nil-указатель до", map[string]*string{"pending_email": nil}, map[string]string{"pending_email": email}, `{"pending_email":{"after":"new@example.com"}}`},
+		{"ничего", nil, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auditDiff(tt.before, tt.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Fatalf("diff = %s, want none", got)
+				}
+				return
+			}
+			var gotV, wantV any
+			if err := json.Unmarshal(got, &gotV); err != nil {
+				t.Fatalf("diff %s: %v", got, err)
+			}
+			if err := json.Unmarshal([]byte(tt.want), &wantV); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotV, wantV) {
+				t.Fatalf("diff = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditDiffRejectsNonObjects(t *testing.T) {
+	if _, err := auditDiff([]int{1}, nil); err == nil {
+		t.Fatal("expected error for non-object value")
+	}
+}
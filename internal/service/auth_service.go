@@ -6,15 +6,45 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/mailer"
 	"market/internal/repository"
 	"market/internal/utils"
 )
 
+var ErrInvalidCredentials = errors.New("invalid credentials")
+var ErrInvalidRefreshToken = errors.New("invalid refresh token")
+var ErrRefreshTokenReuse = errors.New("refresh token reuse detected")
+var ErrWrongPassword = errors.New("invalid current password")
+var ErrPasswordRequired = errors.New("new password required")
+var ErrInvalidMFAToken = errors.New("invalid or expired mfa token")
+var ErrAccountSuspended = errors.New("account suspended")
+
+// purposeMFA — claim purpose токена, выданного между паролем и вторым фактором
+const purposeMFA = "mfa"
+
+type AuthConfig struct {
+	Keys       *utils.KeySet
+	Passwords  *utils.PasswordHasher
+	Token      utils.JWTOptions
+	RefreshTTL time.Duration
+	// Время жизни токена между вводом пароля и кода второго фактора
+	MFATokenTTL time.Duration
+}
+
 type AuthService struct {
-	users     repository.UserRepository
-	jwtSecret string
-	ttl       time.Duration
+	users         repository.UserRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocation    *RevocationService
+	verification  *EmailVerificationService
+	guard         *LoginGuard
+	mfa           *MFAService
+	tx            repository.TxManager
+	publisher     EventPublisher
+	audit         *Auditor
+	cfg           AuthConfig
 }
 
 type RegisterInput struct {
@@ -23,14 +53,19 @@ type RegisterInput struct {
 	Role     domain.Role
 }
 
+// AuthResult — результат входа. При включённом 2FA вместо токенов приходит
+// MFAToken, который обменивается на токены через LoginMFA.
 type AuthResult struct {
-	UserID int64  `json:"user_id"`
-	Token  string `json:"token"`
-	Role   string `json:"role"`
+	UserID       int64  `json:"user_id"`
+	Token        string `json:"token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Role         string `json:"role"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
 
-func NewAuthService(users repository.UserRepository, jwtSecret string, ttl time.Duration) *AuthService {
-	return &AuthService{users: users, jwtSecret: jwtSecret, ttl: ttl}
+func NewAuthService(users repository.UserRepository, refreshTokens repository.RefreshTokenRepository, revocation *RevocationService, verification *EmailVerificationService, guard *LoginGuard, mfa *MFAService, tx repository.TxManager, publisher EventPublisher, audit *Auditor, cfg AuthConfig) *AuthService {
+	return &AuthService{users: users, refreshTokens: refreshTokens, revocation: revocation, verification: verification, guard: guard, mfa: mfa, tx: tx, publisher: publisher, audit: audit, cfg: cfg}
 }
 
 func (s *AuthService) Register(ctx context.Context, in RegisterInput) (*AuthResult, error) {
@@ -38,36 +73,324 @@ func (s *AuthService) Register(ctx context.Context, in RegisterInput) (*AuthResu
 		return nil, errors.New("email and password required")
 	}
 	in.Email = strings.ToLower(strings.TrimSpace(in.Email))
+	if err := mailer.ValidateAddress(in.Email); err != nil {
+		return nil, err
+	}
 	if in.Role != domain.RoleBuyer && in.Role != domain.RoleSeller {
 		return nil, errors.New("invalid role")
 	}
-	hash, err := utils.HashPassword(in.Password)
+	hash, err := s.cfg.Passwords.Hash(in.Password)
 	if err != nil {
 		return nil, err
 	}
-	id, err := s.users.Create(ctx, in.Email, hash, in.Role)
+	var res *AuthResult
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.users.Create(ctx, in.Email, hash, in.Role)
+		if err != nil {
+			return err
+		}
+		if err := s.publisher.Publish(ctx, events.UserRegistered{UserID: id, Email: in.Email, Role: in.Role}); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserRegister, EntityType: "user", EntityID: id, ActorID: id,
+			After: map[string]any{"email": in.Email, "role": in.Role},
+		}); err != nil {
+			return err
+		}
+		if err := s.verification.Send(ctx, id, in.Email); err != nil {
+			return err
+		}
+		res, err = s.startSession(ctx, id, in.Role)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
-	token, err := utils.CreateJWT(id, string(in.Role), s.jwtSecret, s.ttl)
+	return res, nil
+}
+
+// Login проверяет пароль с учётом ограничения попыток по email и IP.
+// При действующей задержке возвращает *LoginThrottledError. Если у пользователя
+// включён TOTP, сессия не создаётся: возвращается MFA-токен для LoginMFA.
+func (s *AuthService) Login(ctx context.Context, email, password, ip string) (*AuthResult, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	attempt, err := s.guard.Attempt(ctx, email, ip)
 	if err != nil {
 		return nil, err
 	}
-	return &AuthResult{UserID: id, Token: token, Role: string(in.Role)}, nil
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		return nil, err
+	}
+	ok, needsRehash := false, false
+	if u != nil {
+		ok, needsRehash, err = s.cfg.Passwords.Verify(u.PasswordHash, password)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		// Хэшируем и для несуществующего email: время ответа не выдаёт, есть ли пользователь
+		s.cfg.Passwords.VerifyDummy(password)
+	}
+	if !ok {
+		if u != nil {
+			if err := s.audit.Record(ctx, AuditRecord{Action: AuditUserLoginFailed, EntityType: "user", EntityID: u.ID}); err != nil {
+				return nil, err
+			}
+		}
+		return nil, ErrInvalidCredentials
+	}
+	// Хэш старым алгоритмом или с устаревшими параметрами пересчитываем, пока пароль под рукой
+	if needsRehash {
+		hash, err := s.cfg.Passwords.Rehash(password)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.users.UpdatePassword(ctx, u.ID, hash); err != nil {
+			return nil, err
+		}
+	}
+	res, err := s.SignIn(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	// Пароль верный: попытка снимается. При включённом 2FA счётчик email не сбрасывается,
+	// чтобы знание пароля не обнуляло ограничение на перебор кодов
+	if res.MFARequired {
+		err = s.guard.Refund(ctx, attempt)
+	} else {
+		err = s.guard.Success(ctx, attempt)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*AuthResult, error) {
-	email = strings.ToLower(strings.TrimSpace(email))
-	u, err := s.users.GetByEmail(ctx, email)
+// SignIn завершает вход пользователя, чья личность уже подтверждена (паролем или
+// внешним провайдером): создаёт сессию или, при включённом TOTP, выдаёт MFA-токен.
+// Заблокированный пользователь не входит.
+func (s *AuthService) SignIn(ctx context.Context, u *domain.User) (*AuthResult, error) {
+	if u.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+	mfaEnabled, err := s.mfa.Enabled(ctx, u.ID)
+	if err != nil {
+		return nil, err
+	}
+	if mfaEnabled {
+		opts := s.cfg.Token
+		opts.TTL = s.cfg.MFATokenTTL
+		token, err := utils.CreatePurposeJWT(u.ID, string(u.Role), purposeMFA, s.cfg.Keys, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &AuthResult{UserID: u.ID, Role: string(u.Role), MFARequired: true, MFAToken: token}, nil
+	}
+	return s.startAuditedSession(ctx, u)
+}
+
+// LoginMFA завершает вход вторым фактором: TOTP-кодом или кодом восстановления.
+// Неверные коды считаются неудачными попытками входа так же, как неверный пароль.
+func (s *AuthService) LoginMFA(ctx context.Context, mfaToken, code, ip string) (*AuthResult, error) {
+	opts := s.cfg.Token
+	opts.TTL = s.cfg.MFATokenTTL
+	claims, err := utils.ParsePurposeJWT(mfaToken, purposeMFA, s.cfg.Keys, opts)
+	if err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	u, err := s.users.GetByID(ctx, claims.UserID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrInvalidMFAToken
+		}
+		return nil, err
+	}
+	if u.SuspendedAt != nil {
+		return nil, ErrAccountSuspended
+	}
+	attempt, err := s.guard.Attempt(ctx, u.Email, ip)
 	if err != nil {
-		return nil, errors.New("invalid credentials")
+		return nil, err
 	}
-	if err := utils.CheckPassword(u.PasswordHash, password); err != nil {
-		return nil, errors.New("invalid credentials")
+	if err := s.mfa.Verify(ctx, u.ID, code); err != nil {
+		return nil, err
 	}
-	token, err := utils.CreateJWT(u.ID, string(u.Role), s.jwtSecret, s.ttl)
+	if err := s.guard.Success(ctx, attempt); err != nil {
+		return nil, err
+	}
+	return s.startAuditedSession(ctx, u)
+}
+
+// Refresh обменивает refresh-токен на новую пару (ротация). Повторное предъявление
+// уже использованного токена означает кражу: вся сессия (family) отзывается.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*AuthResult, error) {
+	var res *AuthResult
+	reused := false
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		t, err := s.refreshTokens.GetByHashForUpdate(ctx, utils.HashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+				return ErrInvalidRefreshToken
+			}
+			return err
+		}
+		if t.RevokedAt != nil {
+			// Отзыв семьи должен закоммититься, поэтому ошибку возвращаем после транзакции
+			reused = true
+			return s.refreshTokens.RevokeFamily(ctx, t.FamilyID)
+		}
+		if time.Now().After(t.ExpiresAt) {
+			return ErrInvalidRefreshToken
+		}
+		u, err := s.users.GetByID(ctx, t.UserID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+		if u.SuspendedAt != nil {
+			return ErrAccountSuspended
+		}
+		// Ротация не продлевает auth_time: свежесть входа считается от ввода учётных данных
+		next, plain, err := s.issueRefreshToken(ctx, u.ID, t.FamilyID, t.AuthenticatedAt)
+		if err != nil {
+			return err
+		}
+		if err := s.refreshTokens.Revoke(ctx, t.ID, &next.ID); err != nil {
+			return err
+		}
+		var authTime time.Time
+		if t.AuthenticatedAt != nil {
+			authTime = *t.AuthenticatedAt
+		}
+		access, err := utils.CreateJWT(u.ID, string(u.Role), authTime, s.cfg.Keys, s.cfg.Token)
+		if err != nil {
+			return err
+		}
+		res = &AuthResult{UserID: u.ID, Token: access, RefreshToken: plain, Role: string(u.Role)}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &AuthResult{UserID: u.ID, Token: token, Role: string(u.Role)}, nil
+	if reused {
+		return nil, ErrRefreshTokenReuse
+	}
+	return res, nil
+}
+
+// Logout отзывает сессию, которой принадлежит refresh-токен, и предъявленный
+// access-токен (если он ещё действителен). Неизвестный токен — не ошибка.
+func (s *AuthService) Logout(ctx context.Context, refreshToken, accessToken string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if accessToken != "" {
+			if claims, err := utils.ParseJWT(accessToken, s.cfg.Keys, s.cfg.Token); err == nil {
+				if err := s.revocation.Revoke(ctx, claims); err != nil {
+					return err
+				}
+				if err := s.audit.Record(ctx, AuditRecord{
+					Action: AuditUserLogout, EntityType: "user", EntityID: claims.UserID, ActorID: claims.UserID,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		t, err := s.refreshTokens.GetByHashForUpdate(ctx, utils.HashToken(refreshToken))
+		if err != nil {
+			if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+				return nil
+			}
+			return err
+		}
+		return s.refreshTokens.RevokeFamily(ctx, t.FamilyID)
+	})
+}
+
+// ChangePassword меняет пароль и завершает все сессии пользователя: refresh-токены
+// отзываются, а access-токены, выпущенные до смены, перестают приниматься.
+// Проверка текущего пароля ограничена LoginGuard, новый хэшируется только после неё.
+func (s *AuthService) ChangePassword(ctx context.Context, userID int64, current, next, ip string) error {
+	if next == "" {
+		return ErrPasswordRequired
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	err = s.guard.Guard(ctx, u.Email, ip, func() error {
+		ok, _, err := s.cfg.Passwords.Verify(u.PasswordHash, current)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrWrongPassword
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	hash, err := s.cfg.Passwords.Hash(next)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.UpdatePassword(ctx, userID, hash); err != nil {
+			return err
+		}
+		if err := s.refreshTokens.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{Action: AuditUserPasswordChange, EntityType: "user", EntityID: userID}); err != nil {
+			return err
+		}
+		return s.revocation.RevokeAllForUser(ctx, userID)
+	})
+}
+
+// startAuditedSession начинает сессию после входа и пишет вход в журнал аудита.
+func (s *AuthService) startAuditedSession(ctx context.Context, u *domain.User) (*AuthResult, error) {
+	var res *AuthResult
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if res, err = s.startSession(ctx, u.ID, u.Role); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditRecord{Action: AuditUserLogin, EntityType: "user", EntityID: u.ID, ActorID: u.ID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// startSession выдаёт access-токен и refresh-токен новой сессии.
+func (s *AuthService) startSession(ctx context.Context, userID int64, role domain.Role) (*AuthResult, error) {
+	now := time.Now()
+	access, err := utils.CreateJWT(userID, string(role), now, s.cfg.Keys, s.cfg.Token)
+	if err != nil {
+		return nil, err
+	}
+	_, refresh, err := s.issueRefreshToken(ctx, userID, uuid.NewString(), &now)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthResult{UserID: userID, Token: access, RefreshToken: refresh, Role: string(role)}, nil
+}
+
+func (s *AuthService) issueRefreshToken(ctx context.Context, userID int64, familyID string, authTime *time.Time) (*domain.RefreshToken, string, error) {
+	plain, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, "", err
+	}
+	t := &domain.RefreshToken{
+		UserID:          userID,
+		FamilyID:        familyID,
+		AuthenticatedAt: authTime,
+		ExpiresAt:       time.Now().Add(s.cfg.RefreshTTL),
+	}
+	if err := s.refreshTokens.Create(ctx, t, hash); err != nil {
+		return nil, "", err
+	}
+	return t, plain, nil
 }
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"market/internal/mailer"
+	"market/internal/repository"
+)
+
+// Background выполняет задачи вне запроса (например, отправку писем): со своим
+// контекстом и таймаутом, ошибки пишутся в лог. Контекст запроса в задачу
+// не передаётся — после ответа fasthttp переиспользует его.
+type Background struct {
+	wg      sync.WaitGroup
+	timeout time.Duration
+	log     *zap.SugaredLogger
+}
+
+func NewBackground(timeout time.Duration, log *zap.SugaredLogger) *Background {
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	return &Background{timeout: timeout, log: log}
+}
+
+// Go запускает fn в отдельной горутине.
+func (b *Background) Go(name string, fn func(ctx context.Context) error) {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+		defer cancel()
+		if err := fn(ctx); err != nil {
+			b.log.Errorw("background task failed", "task", name, "err", err)
+		}
+	}()
+}
+
+// Wait дожидается запущенных задач; вызывается при остановке сервиса.
+func (b *Background) Wait() {
+	b.wg.Wait()
+}
+
+// sendAfterCommit отправляет письмо в фоне после commit транзакции из ctx:
+// при откате письмо не уходит, а сетевые задержки почты не держат блокировки строк.
+func sendAfterCommit(ctx context.Context, jobs *Background, m mailer.Mailer, msg mailer.Message) {
+	repository.AfterCommit(ctx, func() {
+		jobs.Go("mail: "+msg.Subject, func(ctx context.Context) error {
+			return m.Send(ctx, msg)
+		})
+	})
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"market/internal/domain"
+	"market/internal/repository"
+)
+
+type CartService struct {
+	carts    repository.CartRepository
+	products repository.ProductRepository
+}
+
+func NewCartService(carts repository.CartRepository, products repository.ProductRepository) *CartService {
+	return &CartService{carts: carts, products: products}
+}
+
+func (s *CartService) Get(ctx context.Context, userID int64) (*domain.Cart, error) {
+	items, err := s.carts.ListItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	cart := &domain.Cart{UserID: userID, Items: items}
+	for i := range cart.Items {
+		it := &cart.Items[i]
+		it.LineTotalCents = it.PriceCents * int64(it.Quantity)
+		cart.TotalCents += it.LineTotalCents
+	}
+	return cart, nil
+}
+
+// AddItem увеличивает количество товара в корзине на quantity.
+func (s *CartService) AddItem(ctx context.Context, userID, productID int64, quantity int) (*domain.Cart, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if _, err := s.checkProduct(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+	if err := s.carts.AddItem(ctx, userID, productID, quantity); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+// UpdateItem выставляет точное количество товара в корзине.
+func (s *CartService) UpdateItem(ctx context.Context, userID, productID int64, quantity int) (*domain.Cart, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	current, err := s.carts.GetQuantity(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if current == 0 {
+		return nil, repository.ErrCartItemNotFound
+	}
+	if err := s.setItem(ctx, userID, productID, quantity); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, userID, productID int64) (*domain.Cart, error) {
+	if err := s.carts.RemoveItem(ctx, userID, productID); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, userID)
+}
+
+func (s *CartService) Clear(ctx context.Context, userID int64) error {
+	return s.carts.Clear(ctx, userID)
+}
+
+// checkProduct проверяет, что товар существует и не принадлежит покупателю.
+func (s *CartService) checkProduct(ctx context.Context, userID, productID int64) (*domain.Product, error) {
+	p, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if p.SellerID == userID {
+		return nil, repository.ErrOwnProduct
+	}
+	return p, nil
+}
+
+func (s *CartService) setItem(ctx context.Context, userID, productID int64, quantity int) error {
+	p, err := s.checkProduct(ctx, userID, productID)
+	if err != nil {
+		return err
+	}
+	if quantity > p.Stock {
+		return repository.ErrInsufficientStock
+	}
+	return s.carts.SetItem(ctx, userID, productID, quantity)
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/mailer"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+var ErrEmailAlreadyVerified = errors.New("email already verified")
+var ErrVerificationThrottled = errors.New("verification email was sent recently, try again later")
+var ErrEmailNotVerified = errors.New("email not verified")
+
+type EmailVerificationConfig struct {
+	TTL time.Duration
+	// Минимальный интервал между письмами одному пользователю
+	ResendInterval time.Duration
+	// Ссылка из письма; токен дописывается в конец, например "https://market.example/verify?token="
+	LinkURL string
+}
+
+type EmailVerificationService struct {
+	users     repository.UserRepository
+	tokens    repository.EmailVerificationRepository
+	tx        repository.TxManager
+	mailer    mailer.Mailer
+	jobs      *Background
+	publisher EventPublisher
+	cfg       EmailVerificationConfig
+}
+
+func NewEmailVerificationService(users repository.UserRepository, tokens repository.EmailVerificationRepository, tx repository.TxManager, m mailer.Mailer, jobs *Background, publisher EventPublisher, cfg EmailVerificationConfig) *EmailVerificationService {
+	return &EmailVerificationService{users: users, tokens: tokens, tx: tx, mailer: m, jobs: jobs, publisher: publisher, cfg: cfg}
+}
+
+// Send выпускает новый токен для адреса и отправляет письмо. Прежние токены пользователя гасятся.
+// Ошибки доставки не возвращаются, а пишутся в лог; письмо можно запросить повторно через Resend.
+func (s *EmailVerificationService) Send(ctx context.Context, userID int64, email string) error {
+	plain, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokens.InvalidateForUser(ctx, userID); err != nil {
+			return err
+		}
+		t := &domain.EmailVerificationToken{
+			UserID:    userID,
+			Email:     email,
+			ExpiresAt: time.Now().Add(s.cfg.TTL),
+		}
+		if err := s.tokens.Create(ctx, t, hash); err != nil {
+			return err
+		}
+		// Письмо уходит после commit внешней транзакции (например, регистрации):
+		// если она откатится, ссылка с несохранённым токеном не будет отправлена
+		sendAfterCommit(ctx, s.jobs, s.mailer, mailer.Message{
+			To:      email,
+			Subject: "Подтвердите email",
+			Body: fmt.Sprintf("Чтобы подтвердить адрес, перейдите по ссылке:\n%s%s\n\nСсылка действует до %s.",
+				s.cfg.LinkURL, plain, t.ExpiresAt.UTC().Format(time.RFC1123)),
+		})
+		return nil
+	})
+}
+
+// Resend повторно отправляет письмо текущему пользователю, не чаще ResendInterval.
+// Если идёт смена адреса, письмо уходит на новый адрес.
+func (s *EmailVerificationService) Resend(ctx context.Context, userID int64) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	email := u.Email
+	switch {
+	case u.PendingEmail != nil:
+		email = *u.PendingEmail
+	case u.EmailVerifiedAt != nil:
+		return ErrEmailAlreadyVerified
+	}
+	last, err := s.tokens.LastSentAt(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if last != nil && time.Since(*last) < s.cfg.ResendInterval {
+		return ErrVerificationThrottled
+	}
+	return s.Send(ctx, u.ID, email)
+}
+
+// Verify погашает токен и отмечает адрес подтверждённым. Токен на новый адрес
+// (pending_email) завершает смену email. Токен, выпущенный для прежнего адреса, недействителен.
+func (s *EmailVerificationService) Verify(ctx context.Context, token string) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		t, err := s.tokens.GetByHashForUpdate(ctx, utils.HashToken(token))
+		if err != nil {
+			if errors.Is(err, repository.ErrVerificationTokenNotFound) {
+				return ErrInvalidVerificationToken
+			}
+			return err
+		}
+		if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+			return ErrInvalidVerificationToken
+		}
+		if err := s.tokens.MarkUsed(ctx, t.ID); err != nil {
+			return err
+		}
+		ok, err := s.users.MarkEmailVerified(ctx, t.UserID, t.Email)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidVerificationToken
+		}
+		return s.publisher.Publish(ctx, events.UserEmailVerified{UserID: t.UserID, Email: t.Email})
+	})
+}
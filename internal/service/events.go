@@ -0,0 +1,15 @@
+package service
+
+import (
+	"context"
+
+	"market/internal/events"
+)
+
+// EventPublisher — куда сервисы отправляют доменные события. Сервисы публикуют
+// внутри TxManager.WithinTx, поэтому реализация на outbox (repository.OutboxRepository)
+// пишет событие атомарно с изменением. Альтернативы: message_broker.KafkaPublisher
+// (напрямую в брокер) и events.MemoryBus (тесты и локальная разработка).
+type EventPublisher interface {
+	Publish(ctx context.Context, evts ...events.Event) error
+}
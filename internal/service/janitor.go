@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// CleanupFunc удаляет просроченные записи и возвращает, сколько удалено.
+type CleanupFunc func(ctx context.Context) (int64, error)
+
+// Janitor периодически чистит служебные таблицы, чтобы не делать этого на горячем пути
+// запросов. Как и outbox relay, в prefork-режиме запускается только в родительском процессе.
+type Janitor struct {
+	interval time.Duration
+	tasks    []janitorTask
+	log      *zap.SugaredLogger
+}
+
+type janitorTask struct {
+	name string
+	fn   CleanupFunc
+}
+
+func NewJanitor(interval time.Duration, log *zap.SugaredLogger) *Janitor {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	return &Janitor{interval: interval, log: log}
+}
+
+// Add регистрирует задачу очистки; вызывается до Run.
+func (j *Janitor) Add(name string, fn CleanupFunc) {
+	j.tasks = append(j.tasks, janitorTask{name: name, fn: fn})
+}
+
+// Run выполняет все задачи сразу и затем раз в interval до отмены ctx.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		for _, t := range j.tasks {
+			n, err := t.fn(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					j.log.Errorw("janitor: cleanup failed", "task", t.name, "err", err)
+				}
+				continue
+			}
+			if n > 0 {
+				j.log.Infow("janitor: cleaned up", "task", t.name, "rows", n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
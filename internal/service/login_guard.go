@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"market/internal/domain"
+	"market/internal/repository"
+)
+
+// LoginThrottledError — вход временно запрещён; RetryAfter уходит в заголовок Retry-After.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string { return "too many login attempts, try again later" }
+
+type LoginLimit struct {
+	// Сколько неудач подряд допускается без задержки
+	FreeAttempts int
+	// После скольких неудач ключ блокируется на LockoutDuration
+	LockoutAfter int
+}
+
+type LoginGuardConfig struct {
+	Email LoginLimit
+	IP    LoginLimit
+	// Окно, в котором неудачи суммируются
+	Window time.Duration
+	// Задержка после FreeAttempts удваивается с каждой неудачей: BaseDelay, 2*BaseDelay, ... до MaxDelay
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutDuration time.Duration
+}
+
+// LoginGuard считает неудачные входы по email и по IP и выдаёт прогрессивную задержку,
+// а затем временную блокировку.
+//
+// Попытка засчитывается неудачной заранее, в Attempt, атомарно с проверкой задержки
+// (строки ключей блокируются на время проверки). Иначе параллельные запросы успевали бы
+// пройти проверку до того, как первая неудача будет записана. Если попытка оказалась
+// удачной, её снимают Success или Refund.
+type LoginGuard struct {
+	repo repository.LoginAttemptRepository
+	tx   repository.TxManager
+	cfg  LoginGuardConfig
+}
+
+func NewLoginGuard(repo repository.LoginAttemptRepository, tx repository.TxManager, cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{repo: repo, tx: tx, cfg: cfg}
+}
+
+func emailKey(email string) string { return "email:" + email }
+func ipKey(ip string) string       { return "ip:" + ip }
+
+// LoginReservation — попытка, засчитанная Attempt; по ней Success и Refund снимают её.
+type LoginReservation struct {
+	keys []reservedKey // email, затем IP
+}
+
+type reservedKey struct {
+	key       string
+	prevBlock *time.Time // задержка до попытки
+	block     *time.Time // задержка, назначенная попыткой
+}
+
+// Attempt засчитывает попытку входа как неудачную и назначает задержку для следующей.
+// Если для email или IP уже действует задержка или блокировка, попытка не засчитывается
+// и возвращается *LoginThrottledError.
+func (g *LoginGuard) Attempt(ctx context.Context, email, ip string) (*LoginReservation, error) {
+	res := &LoginReservation{}
+	err := g.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Порядок блокировки строк всегда один (email, затем IP), поэтому взаимоблокировок нет
+		keys := []struct {
+			key   string
+			limit LoginLimit
+		}{{emailKey(email), g.cfg.Email}, {ipKey(ip), g.cfg.IP}}
+		attempts := make([]*domain.LoginAttempt, len(keys))
+		now := time.Now()
+		var wait time.Duration
+		for i, k := range keys {
+			a, err := g.repo.GetForUpdate(ctx, k.key)
+			if err != nil {
+				return err
+			}
+			if a.BlockedUntil != nil && a.BlockedUntil.After(now) {
+				wait = max(wait, a.BlockedUntil.Sub(now))
+			}
+			attempts[i] = a
+		}
+		if wait > 0 {
+			return &LoginThrottledError{RetryAfter: wait}
+		}
+		for i, k := range keys {
+			a := attempts[i]
+			rk := reservedKey{key: k.key, prevBlock: a.BlockedUntil}
+			g.count(a, k.limit, now)
+			rk.block = a.BlockedUntil
+			if err := g.repo.Save(ctx, a); err != nil {
+				return err
+			}
+			res.keys = append(res.keys, rk)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Guard выполняет проверку учётных данных fn (пароль, код 2FA) под теми же ограничениями,
+// что и вход: попытка засчитывается до fn и снимается, только если fn завершилась успешно.
+// Иначе с одним access-токеном можно было бы перебирать пароль или коды без задержек.
+func (g *LoginGuard) Guard(ctx context.Context, email, ip string, fn func() error) error {
+	attempt, err := g.Attempt(ctx, email, ip)
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return g.Success(ctx, attempt)
+}
+
+// Success сбрасывает счётчик email и снимает попытку со счётчика IP. Счётчик IP
+// целиком не сбрасывается: иначе перебор по многим аккаунтам с одного адреса
+// обнулялся бы каждым удачным входом.
+func (g *LoginGuard) Success(ctx context.Context, r *LoginReservation) error {
+	if err := g.repo.Reset(ctx, r.keys[0].key); err != nil {
+		return err
+	}
+	return g.refund(ctx, r.keys[1])
+}
+
+// Refund снимает попытку, которая не была неудачной, но и не завершила вход
+// (верный пароль при включённом 2FA), вместе с назначенной ею задержкой.
+func (g *LoginGuard) Refund(ctx context.Context, r *LoginReservation) error {
+	for _, k := range r.keys {
+		if err := g.refund(ctx, k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *LoginGuard) refund(ctx context.Context, k reservedKey) error {
+	return g.repo.Refund(ctx, k.key, k.block, k.prevBlock)
+}
+
+// DeleteExpired удаляет ключи, по которым давно не было неудач; вызывается периодически (см. Janitor).
+func (g *LoginGuard) DeleteExpired(ctx context.Context) (int64, error) {
+	return g.repo.DeleteExpired(ctx, max(g.cfg.Window, g.cfg.LockoutDuration))
+}
+
+// count засчитывает неудачу и назначает задержку по их числу в окне.
+func (g *LoginGuard) count(a *domain.LoginAttempt, limit LoginLimit, now time.Time) {
+	if a.LastFailureAt.Before(now.Add(-g.cfg.Window)) {
+		a.Failures = 0
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	var delay time.Duration
+	switch {
+	case a.Failures >= limit.LockoutAfter:
+		delay = g.cfg.LockoutDuration
+	case a.Failures > limit.FreeAttempts:
+		delay = g.cfg.BaseDelay << min(a.Failures-limit.FreeAttempts-1, 16)
+		delay = min(delay, g.cfg.MaxDelay)
+	default:
+		return
+	}
+	if until := now.Add(delay); a.BlockedUntil == nil || until.After(*a.BlockedUntil) {
+		a.BlockedUntil = &until
+	}
+}
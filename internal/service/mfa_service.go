@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"market/internal/domain"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication already enabled")
+var ErrMFANotEnabled = errors.New("two-factor authentication not enabled")
+var ErrMFANotEnrolled = errors.New("two-factor enrollment not started")
+var ErrInvalidMFACode = errors.New("invalid two-factor code")
+
+type MFAConfig struct {
+	// Issuer показывается в приложении-аутентификаторе
+	Issuer        string
+	RecoveryCodes int
+}
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAService — подключение TOTP и проверка второго фактора.
+type MFAService struct {
+	users     repository.UserRepository
+	repo      repository.MFARepository
+	tx        repository.TxManager
+	box       *utils.SecretBox
+	guard     *LoginGuard
+	passwords *utils.PasswordHasher
+	audit     *Auditor
+	cfg       MFAConfig
+}
+
+func NewMFAService(users repository.UserRepository, repo repository.MFARepository, tx repository.TxManager, box *utils.SecretBox, guard *LoginGuard, passwords *utils.PasswordHasher, audit *Auditor, cfg MFAConfig) *MFAService {
+	return &MFAService{users: users, repo: repo, tx: tx, box: box, guard: guard, passwords: passwords, audit: audit, cfg: cfg}
+}
+
+// Enroll выпускает новый секрет. TOTP включится только после Confirm с кодом из приложения.
+func (s *MFAService) Enroll(ctx context.Context, userID int64) (*TOTPEnrollment, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.repo.SavePendingTOTP(ctx, userID, sealed)
+	if err != nil {
+		return nil, err
+	}
+	if !saved {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return &TOTPEnrollment{Secret: secret, URI: utils.TOTPURI(s.cfg.Issuer, u.Email, secret)}, nil
+}
+
+// QRCode отдаёт PNG с otpauth-ссылкой неподтверждённого секрета.
+func (s *MFAService) QRCode(ctx context.Context, userID int64) ([]byte, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.pending(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.box.Open(t.SecretEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	return qrcode.Encode(utils.TOTPURI(s.cfg.Issuer, u.Email, string(secret)), qrcode.Medium, 256)
+}
+
+// Confirm включает TOTP по первому коду из приложения и возвращает коды восстановления.
+// Коды показываются один раз: в БД хранятся только хэши.
+func (s *MFAService) Confirm(ctx context.Context, userID int64, code string) ([]string, error) {
+	var codes []string
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		t, err := s.pending(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkTOTP(ctx, t, code); err != nil {
+			return err
+		}
+		if err := s.repo.EnableTOTP(ctx, userID); err != nil {
+			return err
+		}
+		if codes, err = s.replaceRecoveryCodes(ctx, userID); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditRecord{Action: AuditUserMFAEnable, EntityType: "user", EntityID: userID})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления; прежние перестают работать.
+// Неверный код считается неудачной попыткой входа, как в AuthService.LoginMFA.
+func (s *MFAService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code, ip string) ([]string, error) {
+	var codes []string
+	err := s.guarded(ctx, userID, ip, func(ctx context.Context, _ *domain.User) error {
+		return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.Verify(ctx, userID, code); err != nil {
+				return err
+			}
+			var err error
+			if codes, err = s.replaceRecoveryCodes(ctx, userID); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, AuditRecord{Action: AuditUserRecoveryCodes, EntityType: "user", EntityID: userID})
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable отключает TOTP; нужны текущий пароль и действующий код или код восстановления,
+// чтобы украденного access-токена не хватало, чтобы снять второй фактор.
+func (s *MFAService) Disable(ctx context.Context, userID int64, password, code, ip string) error {
+	return s.guarded(ctx, userID, ip, func(ctx context.Context, u *domain.User) error {
+		if err := confirmPassword(s.passwords, u, password); err != nil {
+			return err
+		}
+		return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			if err := s.Verify(ctx, userID, code); err != nil {
+				return err
+			}
+			if err := s.repo.DeleteTOTP(ctx, userID); err != nil {
+				return err
+			}
+			return s.audit.Record(ctx, AuditRecord{Action: AuditUserMFADisable, EntityType: "user", EntityID: userID})
+		})
+	})
+}
+
+// guarded выполняет проверку второго фактора под LoginGuard (см. LoginGuard.Guard).
+func (s *MFAService) guarded(ctx context.Context, userID int64, ip string, fn func(ctx context.Context, u *domain.User) error) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.guard.Guard(ctx, u.Email, ip, func() error {
+		return fn(ctx, u)
+	})
+}
+
+func (s *MFAService) Enabled(ctx context.Context, userID int64) (bool, error) {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return t.EnabledAt != nil, nil
+}
+
+// Verify принимает 6-значный TOTP-код или одноразовый код восстановления.
+func (s *MFAService) Verify(ctx context.Context, userID int64, code string) error {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return ErrMFANotEnabled
+		}
+		return err
+	}
+	if t.EnabledAt == nil {
+		return ErrMFANotEnabled
+	}
+	if len(code) == 6 {
+		return s.checkTOTP(ctx, t, code)
+	}
+	used, err := s.repo.UseRecoveryCode(ctx, userID, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) pending(ctx context.Context, userID int64) (*domain.TOTP, error) {
+	t, err := s.repo.GetTOTP(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrTOTPNotFound) {
+			return nil, ErrMFANotEnrolled
+		}
+		return nil, err
+	}
+	if t.EnabledAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return t, nil
+}
+
+// checkTOTP проверяет код и отклоняет повторное использование кода того же шага.
+func (s *MFAService) checkTOTP(ctx context.Context, t *domain.TOTP, code string) error {
+	secret, err := s.box.Open(t.SecretEncrypted)
+	if err != nil {
+		return err
+	}
+	step, ok := utils.ValidateTOTP(string(secret), code, time.Now())
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	fresh, err := s.repo.UseStep(ctx, t.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *MFAService) replaceRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, s.cfg.RecoveryCodes)
+	hashes := make([]string, s.cfg.RecoveryCodes)
+	for i := range codes {
+		c, err := utils.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i], hashes[i] = c, utils.HashToken(c)
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrUnknownOIDCProvider = errors.New("unknown identity provider")
+var ErrInvalidOIDCState = errors.New("invalid or expired oidc state")
+var ErrOIDCLoginFailed = errors.New("oidc login failed")
+var ErrOIDCEmailNotVerified = errors.New("identity provider did not return a verified email")
+var ErrOIDCAccountConflict = errors.New("account with this email exists but is not verified, log in with password first")
+
+type OIDCProviderConfig struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OIDCConfig struct {
+	Providers map[string]OIDCProviderConfig
+	// Сколько живёт незавершённый вход между /login и /callback
+	StateTTL time.Duration
+}
+
+// oidcProvider подключается к провайдеру (discovery) при первом обращении,
+// чтобы недоступный провайдер не мешал старту сервиса.
+type oidcProvider struct {
+	name     string
+	cfg      OIDCProviderConfig
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// OIDCService — вход через внешних провайдеров: authorization code + PKCE.
+type OIDCService struct {
+	users      repository.UserRepository
+	identities repository.IdentityRepository
+	tx         repository.TxManager
+	publisher  EventPublisher
+	auth       *AuthService
+	providers  map[string]*oidcProvider
+	client     *http.Client
+	cfg        OIDCConfig
+}
+
+func NewOIDCService(users repository.UserRepository, identities repository.IdentityRepository, tx repository.TxManager, publisher EventPublisher, auth *AuthService, cfg OIDCConfig) *OIDCService {
+	providers := make(map[string]*oidcProvider, len(cfg.Providers))
+	for name, pc := range cfg.Providers {
+		if len(pc.Scopes) == 0 {
+			pc.Scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+		}
+		providers[name] = &oidcProvider{name: name, cfg: pc}
+	}
+	return &OIDCService{
+		users:      users,
+		identities: identities,
+		tx:         tx,
+		publisher:  publisher,
+		auth:       auth,
+		providers:  providers,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		cfg:        cfg,
+	}
+}
+
+func (s *OIDCService) provider(name string) (*oidcProvider, error) {
+	p, ok := s.providers[name]
+	if !ok {
+		return nil, ErrUnknownOIDCProvider
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.oauth != nil {
+		return p, nil
+	}
+	// Контекст провайдера живёт дольше запроса: через него позже подгружаются ключи JWKS
+	ctx := oidc.ClientContext(context.Background(), s.client)
+	prov, err := oidc.NewProvider(ctx, p.cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery %s: %w", name, err)
+	}
+	p.oauth = &oauth2.Config{
+		ClientID:     p.cfg.ClientID,
+		ClientSecret: p.cfg.ClientSecret,
+		RedirectURL:  p.cfg.RedirectURL,
+		Endpoint:     prov.Endpoint(),
+		Scopes:       p.cfg.Scopes,
+	}
+	p.verifier = prov.Verifier(&oidc.Config{ClientID: p.cfg.ClientID})
+	return p, nil
+}
+
+// OIDCStart — начатый вход: куда отправить браузер и значение state, которое
+// обработчик кладёт в HttpOnly-cookie до ExpiresAt.
+type OIDCStart struct {
+	URL       string
+	State     string
+	ExpiresAt time.Time
+}
+
+// AuthURL начинает вход: сохраняет state, nonce и PKCE verifier и возвращает
+// адрес страницы входа провайдера.
+func (s *OIDCService) AuthURL(ctx context.Context, providerName string) (*OIDCStart, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	state, _, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, _, err := utils.NewOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	st := &domain.OIDCState{
+		State:        state,
+		Provider:     p.name,
+		Nonce:        nonce,
+		CodeVerifier: oauth2.GenerateVerifier(),
+		ExpiresAt:    time.Now().Add(s.cfg.StateTTL),
+	}
+	if err := s.identities.SaveState(ctx, st); err != nil {
+		return nil, err
+	}
+	return &OIDCStart{
+		URL:       p.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(st.CodeVerifier)),
+		State:     state,
+		ExpiresAt: st.ExpiresAt,
+	}, nil
+}
+
+type oidcClaims struct {
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// Callback обменивает code на токены, проверяет id_token и входит пользователем,
+// связанным с учётной записью провайдера. При первом входе учётная запись
+// привязывается к пользователю с тем же подтверждённым email или создаётся новый покупатель.
+//
+// browserState — значение cookie, выставленной при AuthURL. Оно должно совпасть со state
+// из адреса: иначе ссылку с кодом злоумышленника можно подсунуть жертве, и та войдёт
+// в его учётную запись (login CSRF).
+func (s *OIDCService) Callback(ctx context.Context, providerName, state, browserState, code string) (*AuthResult, error) {
+	p, err := s.provider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, ErrInvalidOIDCState
+	}
+	st, err := s.identities.TakeState(ctx, state)
+	if err != nil {
+		if errors.Is(err, repository.ErrOIDCStateNotFound) {
+			return nil, ErrInvalidOIDCState
+		}
+		return nil, err
+	}
+	if st.Provider != p.name || time.Now().After(st.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	httpCtx := context.WithValue(ctx, oauth2.HTTPClient, s.client)
+	tok, err := p.oauth.Exchange(httpCtx, code, oauth2.VerifierOption(st.CodeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	rawID, ok := tok.Extra("id_token").(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: no id_token in response", ErrOIDCLoginFailed)
+	}
+	idt, err := p.verifier.Verify(httpCtx, rawID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+	if idt.Nonce != st.Nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrOIDCLoginFailed)
+	}
+	var claims oidcClaims
+	if err := idt.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrOIDCLoginFailed, err)
+	}
+
+	// Два одновременных первых входа одного пользователя конфликтуют на UNIQUE
+	// (email или identity). Проигравший повторяет поиск и находит созданное победителем.
+	var u *domain.User
+	for attempt := 0; ; attempt++ {
+		err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			u, err = s.resolveUser(ctx, p.name, idt.Subject, claims)
+			return err
+		})
+		conflict := errors.Is(err, repository.ErrEmailTaken) || errors.Is(err, repository.ErrIdentityExists)
+		if !conflict || attempt == 1 {
+			break
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.auth.SignIn(ctx, u)
+}
+
+func (s *OIDCService) resolveUser(ctx context.Context, provider, subject string, claims oidcClaims) (*domain.User, error) {
+	ident, err := s.identities.Get(ctx, provider, subject)
+	if err == nil {
+		return s.users.GetByID(ctx, ident.UserID)
+	}
+	if !errors.Is(err, repository.ErrIdentityNotFound) {
+		return nil, err
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+	u, err := s.users.GetByEmail(ctx, email)
+	switch {
+	case err == nil:
+		// Привязываем только к подтверждённому адресу: иначе тот, кто заранее
+		// зарегистрировал чужой email с паролем, получил бы доступ к аккаунту владельца
+		if u.EmailVerifiedAt == nil {
+			return nil, ErrOIDCAccountConflict
+		}
+	case errors.Is(err, repository.ErrUserNotFound):
+		// Новый пользователь без пароля; задать пароль можно через сброс
+		id, err := s.users.Create(ctx, email, "", domain.RoleBuyer)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := s.users.MarkEmailVerified(ctx, id, email); err != nil {
+			return nil, err
+		}
+		if err := s.publisher.Publish(ctx,
+			events.UserRegistered{UserID: id, Email: email, Role: domain.RoleBuyer},
+			events.UserEmailVerified{UserID: id, Email: email},
+		); err != nil {
+			return nil, err
+		}
+		if u, err = s.users.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	if err := s.identities.Create(ctx, &domain.Identity{UserID: u.ID, Provider: provider, Subject: subject, Email: email}); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
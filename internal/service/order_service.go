@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/repository"
+)
+
+// orderTransitions — допустимые переходы статусов заказа.
+var orderTransitions = map[domain.OrderStatus][]domain.OrderStatus{
+	domain.OrderPending:   {domain.OrderPaid, domain.OrderCancelled},
+	domain.OrderPaid:      {domain.OrderShipped, domain.OrderCancelled, domain.OrderRefunded},
+	domain.OrderShipped:   {domain.OrderDelivered},
+	domain.OrderDelivered: {domain.OrderRefunded},
+}
+
+func canTransition(from, to domain.OrderStatus) bool {
+	for _, s := range orderTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+type OrderService struct {
+	orders    repository.OrderRepository
+	carts     repository.CartRepository
+	tx        repository.TxManager
+	publisher EventPublisher
+}
+
+func NewOrderService(orders repository.OrderRepository, carts repository.CartRepository, tx repository.TxManager, publisher EventPublisher) *OrderService {
+	return &OrderService{orders: orders, carts: carts, tx: tx, publisher: publisher}
+}
+
+// Checkout оформляет заказ из переданных позиций, а если их нет — из корзины покупателя.
+func (s *OrderService) Checkout(ctx context.Context, buyerID int64, lines []domain.OrderLine) ([]domain.Order, error) {
+	fromCart := len(lines) == 0
+	if fromCart {
+		items, err := s.carts.ListItems(ctx, buyerID)
+		if err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			lines = append(lines, domain.OrderLine{ProductID: it.ProductID, Quantity: it.Quantity})
+		}
+		if len(lines) == 0 {
+			return nil, errors.New("cart is empty")
+		}
+	}
+	for _, l := range lines {
+		if l.Quantity <= 0 {
+			return nil, errors.New("quantity must be positive")
+		}
+	}
+	var orders []domain.Order
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		orders, err = s.orders.Checkout(ctx, buyerID, lines, fromCart)
+		if err != nil {
+			return err
+		}
+		placed := make([]events.Event, 0, len(orders))
+		for _, o := range orders {
+			placed = append(placed, events.OrderPlaced{Order: o})
+		}
+		return s.publisher.Publish(ctx, placed...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (s *OrderService) Get(ctx context.Context, buyerID, orderID int64) (*domain.Order, error) {
+	o, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.BuyerID != buyerID {
+		return nil, errors.New("forbidden: not owner")
+	}
+	return o, nil
+}
+
+func (s *OrderService) List(ctx context.Context, buyerID int64, limit, offset int32) ([]domain.Order, error) {
+	return s.orders.ListByBuyer(ctx, buyerID, limit, offset)
+}
+
+func (s *OrderService) ListForSeller(ctx context.Context, sellerID int64, status domain.OrderStatus, limit, offset int32) ([]domain.Order, error) {
+	return s.orders.ListBySeller(ctx, sellerID, status, limit, offset)
+}
+
+// UpdateStatus переводит заказ продавца в статус to. Отмена оплаченного заказа
+// возвращает деньги и остатки, возврат (refunded) — только деньги.
+func (s *OrderService) UpdateStatus(ctx context.Context, sellerID, orderID int64, to domain.OrderStatus) (*domain.Order, error) {
+	o, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.SellerID != sellerID {
+		return nil, errors.New("forbidden: not owner")
+	}
+	if !canTransition(o.Status, to) {
+		return nil, fmt.Errorf("invalid status transition: %s -> %s", o.Status, to)
+	}
+	paid := o.Status != domain.OrderPending
+	var updated *domain.Order
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		updated, err = s.orders.ChangeStatus(ctx, repository.StatusChange{
+			OrderID: orderID,
+			From:    o.Status,
+			To:      to,
+			ActorID: sellerID,
+			Refund:  paid && (to == domain.OrderCancelled || to == domain.OrderRefunded),
+			Restock: to == domain.OrderCancelled,
+		})
+		if err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.OrderStatusChanged{
+			OrderID:   orderID,
+			From:      o.Status,
+			To:        to,
+			ChangedBy: sellerID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func (s *OrderService) History(ctx context.Context, userID, orderID int64) ([]domain.OrderStatusChange, error) {
+	o, err := s.orders.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if o.BuyerID != userID && o.SellerID != userID {
+		return nil, errors.New("forbidden: not owner")
+	}
+	return s.orders.History(ctx, orderID)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"market/internal/domain"
+	"market/internal/mailer"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+type PasswordResetConfig struct {
+	Passwords *utils.PasswordHasher
+	TTL       time.Duration
+	// Ссылка из письма; токен дописывается в конец
+	LinkURL string
+}
+
+type PasswordResetService struct {
+	users         repository.UserRepository
+	tokens        repository.PasswordResetRepository
+	refreshTokens repository.RefreshTokenRepository
+	revocation    *RevocationService
+	tx            repository.TxManager
+	mailer        mailer.Mailer
+	jobs          *Background
+	audit         *Auditor
+	cfg           PasswordResetConfig
+}
+
+func NewPasswordResetService(users repository.UserRepository, tokens repository.PasswordResetRepository, refreshTokens repository.RefreshTokenRepository, revocation *RevocationService, tx repository.TxManager, m mailer.Mailer, jobs *Background, audit *Auditor, cfg PasswordResetConfig) *PasswordResetService {
+	return &PasswordResetService{users: users, tokens: tokens, refreshTokens: refreshTokens, revocation: revocation, tx: tx, mailer: m, jobs: jobs, audit: audit, cfg: cfg}
+}
+
+// Forgot отправляет ссылку для сброса пароля. Поиск пользователя, выпуск токена
+// и письмо выполняются в фоне, а Forgot сразу возвращается: ни ответ, ни время ответа
+// не выдают, есть ли пользователь с таким email. Ошибки пишутся в лог.
+func (s *PasswordResetService) Forgot(_ context.Context, email string) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	s.jobs.Go("password reset", func(ctx context.Context) error {
+		return s.issue(ctx, email)
+	})
+	return nil
+}
+
+// issue выпускает токен сброса; для неизвестного email молча ничего не делает.
+func (s *PasswordResetService) issue(ctx context.Context, email string) error {
+	u, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+	plain, hash, err := utils.NewOpaqueToken()
+	if err != nil {
+		return err
+	}
+	t := &domain.PasswordResetToken{UserID: u.ID, ExpiresAt: time.Now().Add(s.cfg.TTL)}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tokens.InvalidateForUser(ctx, u.ID); err != nil {
+			return err
+		}
+		return s.tokens.Create(ctx, t, hash)
+	})
+	if err != nil {
+		return err
+	}
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      u.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Чтобы задать новый пароль, перейдите по ссылке:\n%s%s\n\nСсылка действует до %s. Если вы не запрашивали сброс, просто проигнорируйте это письмо.",
+			s.cfg.LinkURL, plain, t.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// Reset задаёт новый пароль по токену из письма и завершает все сессии пользователя.
+func (s *PasswordResetService) Reset(ctx context.Context, token, newPassword string) error {
+	if newPassword == "" {
+		return ErrPasswordRequired
+	}
+	tokenHash := utils.HashToken(token)
+	// Дешёвая проверка без блокировки до хэширования: иначе любой анонимный запрос
+	// с мусорным токеном стоил бы полного argon2id
+	if _, err := validResetToken(s.tokens.GetByHash(ctx, tokenHash)); err != nil {
+		return err
+	}
+	hash, err := s.cfg.Passwords.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// повторно под блокировкой: токен могли использовать, пока считался хэш
+		t, err := validResetToken(s.tokens.GetByHashForUpdate(ctx, tokenHash))
+		if err != nil {
+			return err
+		}
+		if err := s.tokens.MarkUsed(ctx, t.ID); err != nil {
+			return err
+		}
+		if err := s.users.UpdatePassword(ctx, t.UserID, hash); err != nil {
+			return err
+		}
+		if err := s.refreshTokens.RevokeAllForUser(ctx, t.UserID); err != nil {
+			return err
+		}
+		// запрос анонимный: автор — владелец токена
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserPasswordReset, EntityType: "user", EntityID: t.UserID, ActorID: t.UserID,
+		}); err != nil {
+			return err
+		}
+		return s.revocation.RevokeAllForUser(ctx, t.UserID)
+	})
+}
+
+// validResetToken отбрасывает неизвестные, использованные и просроченные токены.
+func validResetToken(t *domain.PasswordResetToken, err error) (*domain.PasswordResetToken, error) {
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenNotFound) {
+			return nil, ErrInvalidResetToken
+		}
+		return nil, err
+	}
+	if t.UsedAt != nil || time.Now().After(t.ExpiresAt) {
+		return nil, ErrInvalidResetToken
+	}
+	return t, nil
+}
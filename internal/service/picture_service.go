@@ -5,48 +5,76 @@ import (
 	"errors"
 
 	"market/internal/domain"
+	"market/internal/events"
 	"market/internal/repository"
 )
 
 type PictureService struct {
-	products repository.ProductRepository
-	pictures repository.PictureRepository
-	maxSize  int64
+	products  repository.ProductRepository
+	pictures  repository.PictureRepository
+	tx        repository.TxManager
+	publisher EventPublisher
+	audit     *Auditor
+	maxSize   int64
 }
 
-func NewPictureService(products repository.ProductRepository, pictures repository.PictureRepository) *PictureService {
+func NewPictureService(products repository.ProductRepository, pictures repository.PictureRepository, tx repository.TxManager, publisher EventPublisher, audit *Auditor) *PictureService {
 	return &PictureService{
-		products: products,
-		pictures: pictures,
-		maxSize:  10 << 20, // 10 MiB
+		products:  products,
+		pictures:  pictures,
+		tx:        tx,
+		publisher: publisher,
+		audit:     audit,
+		maxSize:   10 << 20, // 10 MiB
 	}
 }
 
-func (s *PictureService) UploadAndAttach(ctx context.Context, sellerID, productID int64, data []byte, mime string) (*domain.Picture, error) {
+// pictureRef — что пишется в аудит о картинке товара
+type pictureRef struct {
+	PictureID int64  `json:"picture_id"`
+	MIMEType  string `json:"mime_type,omitempty"`
+	Position  int    `json:"position,omitempty"`
+}
+
+func (s *PictureService) UploadAndAttach(ctx context.Context, actor domain.Actor, productID int64, data []byte, mime string) (*domain.Picture, error) {
 	if int64(len(data)) == 0 || int64(len(data)) > s.maxSize {
 		return nil, errors.New("invalid file size")
 	}
-	p, err := s.products.GetByID(ctx, productID)
+	p, err := s.products.GetByIDIncludingHidden(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
-	if p.SellerID != sellerID {
+	if !actor.CanManage(p.SellerID, domain.PermProductWrite, domain.PermProductWriteAny) {
 		return nil, errors.New("forbidden: not owner")
 	}
-	picID, err := s.pictures.Create(ctx, data, mime)
-	if err != nil {
-		return nil, err
-	}
-	pos, err := s.pictures.AttachAutoPosition(ctx, productID, picID)
+	pic := &domain.Picture{MIMEType: mime, SizeBytes: int64(len(data))}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		picID, err := s.pictures.Create(ctx, data, mime)
+		if err != nil {
+			return err
+		}
+		pos, err := s.pictures.AttachAutoPosition(ctx, productID, picID)
+		if err != nil {
+			return err
+		}
+		pic.ID, pic.Position = picID, pos
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditPictureAttach, EntityType: "product", EntityID: productID,
+			After: pictureRef{PictureID: picID, MIMEType: mime, Position: pos},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.PictureAttached{
+			ProductID: productID,
+			PictureID: picID,
+			MIMEType:  mime,
+			Position:  pos,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
-	return &domain.Picture{
-		ID:        picID,
-		MIMEType:  mime,
-		SizeBytes: int64(len(data)),
-		Position:  pos,
-	}, nil
+	return pic, nil
 }
 
 func (s *PictureService) List(ctx context.Context, productID int64) ([]domain.Picture, error) {
@@ -57,30 +85,58 @@ func (s *PictureService) Download(ctx context.Context, pictureID int64) ([]byte,
 	return s.pictures.GetData(ctx, pictureID)
 }
 
-func (s *PictureService) Detach(ctx context.Context, sellerID, productID, pictureID int64, hardDelete bool) error {
-	p, err := s.products.GetByID(ctx, productID)
+func (s *PictureService) Detach(ctx context.Context, actor domain.Actor, productID, pictureID int64, hardDelete bool) error {
+	p, err := s.products.GetByIDIncludingHidden(ctx, productID)
 	if err != nil {
 		return err
 	}
-	if p.SellerID != sellerID {
+	if !actor.CanManage(p.SellerID, domain.PermProductWrite, domain.PermProductWriteAny) {
 		return errors.New("forbidden: not owner")
 	}
-	if err := s.pictures.Detach(ctx, productID, pictureID); err != nil {
-		return err
-	}
-	if hardDelete {
-		return s.pictures.DeletePicture(ctx, pictureID)
-	}
-	return nil
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.pictures.Detach(ctx, productID, pictureID); err != nil {
+			return err
+		}
+		action := AuditPictureDetach
+		if hardDelete {
+			if err := s.pictures.DeletePicture(ctx, pictureID); err != nil {
+				return err
+			}
+			action = AuditPictureDelete
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: action, EntityType: "product", EntityID: productID,
+			Before: pictureRef{PictureID: pictureID},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.PictureDetached{
+			ProductID: productID,
+			PictureID: pictureID,
+			Deleted:   hardDelete,
+		})
+	})
 }
 
-func (s *PictureService) SetCover(ctx context.Context, sellerID, productID, pictureID int64) error {
-	p, err := s.products.GetByID(ctx, productID)
+func (s *PictureService) SetCover(ctx context.Context, actor domain.Actor, productID, pictureID int64) error {
+	p, err := s.products.GetByIDIncludingHidden(ctx, productID)
 	if err != nil {
 		return err
 	}
-	if p.SellerID != sellerID {
+	if !actor.CanManage(p.SellerID, domain.PermProductWrite, domain.PermProductWriteAny) {
 		return errors.New("forbidden: not owner")
 	}
-	return s.pictures.SetCoverIfAttached(ctx, productID, pictureID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.pictures.SetCoverIfAttached(ctx, productID, pictureID); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditProductCover, EntityType: "product", EntityID: productID,
+			Before: map[string]*int64{"cover_picture_id": p.CoverPictureID},
+			After:  map[string]*int64{"cover_picture_id": &pictureID},
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.ProductCoverChanged{ProductID: productID, PictureID: pictureID})
+	})
 }
@@ -5,15 +5,20 @@ import (
 	"errors"
 
 	"market/internal/domain"
+	"market/internal/events"
 	"market/internal/repository"
 )
 
 type ProductService struct {
-	repo repository.ProductRepository
+	repo      repository.ProductRepository
+	users     repository.UserRepository
+	tx        repository.TxManager
+	publisher EventPublisher
+	audit     *Auditor
 }
 
-func NewProductService(repo repository.ProductRepository) *ProductService {
-	return &ProductService{repo: repo}
+func NewProductService(repo repository.ProductRepository, users repository.UserRepository, tx repository.TxManager, publisher EventPublisher, audit *Auditor) *ProductService {
+	return &ProductService{repo: repo, users: users, tx: tx, publisher: publisher, audit: audit}
 }
 
 type ProductCreateInput struct {
@@ -30,6 +35,14 @@ func (s *ProductService) Create(ctx context.Context, sellerID int64, in ProductC
 	if in.Name == "" || in.PriceCents < 0 || in.Stock < 0 {
 		return nil, errors.New("invalid product data")
 	}
+	// Продавать можно только с подтверждённого адреса
+	seller, err := s.users.GetByID(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if seller.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
 	p := &domain.Product{
 		SellerID:       sellerID,
 		Name:           in.Name,
@@ -38,45 +51,78 @@ func (s *ProductService) Create(ctx context.Context, sellerID int64, in ProductC
 		Stock:          in.Stock,
 		CoverPictureID: in.CoverPictureID,
 	}
-	id, err := s.repo.Create(ctx, p)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repo.Create(ctx, p)
+		if err != nil {
+			return err
+		}
+		p.ID = id
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditProductCreate, EntityType: "product", EntityID: id, After: p,
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.ProductCreated{Product: *p})
+	})
 	if err != nil {
 		return nil, err
 	}
-	p.ID = id
 	return p, nil
 }
 
-func (s *ProductService) Update(ctx context.Context, sellerID, productID int64, in ProductUpdateInput) (*domain.Product, error) {
+func (s *ProductService) Update(ctx context.Context, actor domain.Actor, productID int64, in ProductUpdateInput) (*domain.Product, error) {
 	if in.Name == "" || in.PriceCents < 0 || in.Stock < 0 {
 		return nil, errors.New("invalid product data")
 	}
-	p, err := s.repo.GetByID(ctx, productID)
+	p, err := s.repo.GetByIDIncludingHidden(ctx, productID)
 	if err != nil {
 		return nil, err
 	}
-	if p.SellerID != sellerID {
+	if !actor.CanManage(p.SellerID, domain.PermProductWrite, domain.PermProductWriteAny) {
 		return nil, errors.New("forbidden: not owner")
 	}
+	before := *p
 	p.Name = in.Name
 	p.Description = in.Description
 	p.PriceCents = in.PriceCents
 	p.Stock = in.Stock
 	p.CoverPictureID = in.CoverPictureID
-	if err := s.repo.Update(ctx, p); err != nil {
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, p); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditProductUpdate, EntityType: "product", EntityID: p.ID, Before: before, After: p,
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.ProductUpdated{Product: *p})
+	})
+	if err != nil {
 		return nil, err
 	}
 	return p, nil
 }
 
-func (s *ProductService) Delete(ctx context.Context, sellerID, productID int64) error {
-	p, err := s.repo.GetByID(ctx, productID)
+func (s *ProductService) Delete(ctx context.Context, actor domain.Actor, productID int64) error {
+	p, err := s.repo.GetByIDIncludingHidden(ctx, productID)
 	if err != nil {
 		return err
 	}
-	if p.SellerID != sellerID {
+	if !actor.CanManage(p.SellerID, domain.PermProductWrite, domain.PermProductWriteAny) {
 		return errors.New("forbidden: not owner")
 	}
-	return s.repo.Delete(ctx, productID)
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, productID); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditProductDelete, EntityType: "product", EntityID: productID, Before: p,
+		}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.ProductDeleted{ProductID: productID, SellerID: p.SellerID})
+	})
 }
 
 func (s *ProductService) Get(ctx context.Context, productID int64) (*domain.Product, error) {
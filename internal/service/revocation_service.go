@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+type RevocationConfig struct {
+	CacheSize int
+}
+
+// RevocationService проверяет, не отозван ли access-токен: по jti (денылист),
+// по водяному знаку пользователя tokens_valid_after и по блокировке владельца.
+//
+// Отрицательные ответы не кэшируются: процессы prefork и реплики не делят память,
+// и закэшированное «не отозван» пропускало бы отозванный токен. Поэтому каждый
+// запрос делает одно обращение к БД. В LRU попадают только jti, которые уже
+// прочитаны из БД как отозванные, — этот ответ не устаревает до exp токена.
+type RevocationService struct {
+	repo    repository.RevocationRepository
+	revoked *utils.LRU[string, bool]
+}
+
+func NewRevocationService(repo repository.RevocationRepository, cfg RevocationConfig) *RevocationService {
+	return &RevocationService{
+		repo:    repo,
+		revoked: utils.NewLRU[string, bool](cfg.CacheSize),
+	}
+}
+
+func (s *RevocationService) IsRevoked(ctx context.Context, claims *utils.AuthClaims) (bool, error) {
+	if _, ok := s.revoked.Get(claims.ID); ok {
+		return true, nil
+	}
+	st, err := s.repo.TokenStatus(ctx, claims.UserID, claims.ID)
+	if err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return true, nil
+		}
+		return false, err
+	}
+	if st.Revoked {
+		s.revoked.Set(claims.ID, true, ttlUntil(claims))
+		return true, nil
+	}
+	// Блокировка проверяется независимо от водяного знака: её могут снять и поставить
+	// снова, а токен не должен пережить ни одну из них. В кэш не кладём — блокировку снимают.
+	if st.Suspended {
+		return true, nil
+	}
+	if st.ValidAfter == nil {
+		return false, nil
+	}
+	// iat и водяной знак сравниваются с точностью до миллисекунды (см. utils.CreateJWT):
+	// токен, выпущенный сразу после смены пароля, уже действителен
+	return claims.IssuedAt == nil || !claims.IssuedAt.After(*st.ValidAfter), nil
+}
+
+// Revoke добавляет токен в денылист до истечения его срока. Кэш не трогаем:
+// внешняя транзакция может откатиться, а отозванный jti попадёт в LRU при первой проверке.
+func (s *RevocationService) Revoke(ctx context.Context, claims *utils.AuthClaims) error {
+	return s.repo.RevokeToken(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
+}
+
+// RevokeAllForUser делает недействительными все access-токены пользователя, выпущенные до этого момента.
+func (s *RevocationService) RevokeAllForUser(ctx context.Context, userID int64) error {
+	return s.repo.BumpTokensValidAfter(ctx, userID, time.Now().Truncate(time.Millisecond))
+}
+
+// DeleteExpired чистит денылист от записей с истёкшим exp; вызывается периодически (см. Janitor).
+func (s *RevocationService) DeleteExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
+
+func ttlUntil(claims *utils.AuthClaims) time.Duration {
+	if claims.ExpiresAt == nil {
+		return time.Minute
+	}
+	return time.Until(claims.ExpiresAt.Time)
+}
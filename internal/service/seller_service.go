@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"market/internal/domain"
+	"market/internal/mailer"
+	"market/internal/repository"
+)
+
+var ErrInvalidSlug = errors.New("slug must be 3-50 lowercase latin letters, digits or hyphens")
+var ErrInvalidShopName = errors.New("shop name must be 1-100 characters")
+var ErrDescriptionTooLong = errors.New("description must be at most 2000 characters")
+
+const (
+	maxShopNameLen    = 100
+	maxDescriptionLen = 2000
+)
+
+var slugRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,48}[a-z0-9]$`)
+
+type SellerService struct {
+	profiles    repository.SellerProfileRepository
+	products    repository.ProductRepository
+	users       repository.UserRepository
+	pictures    repository.PictureRepository
+	tx          repository.TxManager
+	audit       *Auditor
+	maxLogoSize int64
+}
+
+func NewSellerService(profiles repository.SellerProfileRepository, products repository.ProductRepository, users repository.UserRepository, pictures repository.PictureRepository, tx repository.TxManager, audit *Auditor) *SellerService {
+	return &SellerService{
+		profiles:    profiles,
+		products:    products,
+		users:       users,
+		pictures:    pictures,
+		tx:          tx,
+		audit:       audit,
+		maxLogoSize: 2 << 20, // 2 MiB
+	}
+}
+
+// SellerProfileInput: slug можно не указывать — он строится из названия магазина.
+type SellerProfileInput struct {
+	Slug         string  `json:"slug"`
+	ShopName     string  `json:"shop_name"`
+	Description  string  `json:"description"`
+	ContactEmail *string `json:"contact_email"`
+	ContactPhone *string `json:"contact_phone"`
+}
+
+// GetBySlug возвращает публичную витрину. Витрины удалённых и заблокированных
+// продавцов не отдаются: репозиторий отвечает для них ErrSellerProfileNotFound.
+func (s *SellerService) GetBySlug(ctx context.Context, slug string) (*domain.SellerProfile, error) {
+	return s.profiles.GetBySlug(ctx, strings.ToLower(slug))
+}
+
+// ListProducts — товары витрины, тот же фильтр, что у GET /products, но по одному продавцу.
+func (s *SellerService) ListProducts(ctx context.Context, slug string, limit, offset int32, q string) ([]domain.Product, error) {
+	p, err := s.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.products.List(ctx, repository.ProductFilter{
+		Limit:    limit,
+		Offset:   offset,
+		Query:    q,
+		SellerID: p.UserID,
+	})
+}
+
+func (s *SellerService) GetMine(ctx context.Context, sellerID int64) (*domain.SellerProfile, error) {
+	return s.profiles.GetByUserID(ctx, sellerID)
+}
+
+// SaveProfile создаёт или полностью заменяет витрину. Как и товары, витрина
+// публикуется только с подтверждённого адреса.
+func (s *SellerService) SaveProfile(ctx context.Context, sellerID int64, in SellerProfileInput) (*domain.SellerProfile, error) {
+	p := &domain.SellerProfile{
+		UserID:      sellerID,
+		ShopName:    strings.TrimSpace(in.ShopName),
+		Description: strings.TrimSpace(in.Description),
+	}
+	if n := utf8.RuneCountInString(p.ShopName); n == 0 || n > maxShopNameLen {
+		return nil, ErrInvalidShopName
+	}
+	if utf8.RuneCountInString(p.Description) > maxDescriptionLen {
+		return nil, ErrDescriptionTooLong
+	}
+	p.Slug = strings.ToLower(strings.TrimSpace(in.Slug))
+	if p.Slug == "" {
+		p.Slug = slugify(p.ShopName)
+	}
+	if !slugRe.MatchString(p.Slug) || strings.Contains(p.Slug, "--") {
+		return nil, ErrInvalidSlug
+	}
+	if in.ContactEmail != nil {
+		email := strings.ToLower(strings.TrimSpace(*in.ContactEmail))
+		if email != "" {
+			if err := mailer.ValidateAddress(email); err != nil {
+				return nil, err
+			}
+		}
+		p.ContactEmail = nilIfEmpty(email)
+	}
+	if in.ContactPhone != nil {
+		phone := normalizePhone(*in.ContactPhone)
+		if phone != "" && !phoneRe.MatchString(phone) {
+			return nil, ErrInvalidPhone
+		}
+		p.ContactPhone = nilIfEmpty(phone)
+	}
+
+	u, err := s.users.GetByID(ctx, sellerID)
+	if err != nil {
+		return nil, err
+	}
+	if u.EmailVerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+	if err := s.profiles.Upsert(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SetLogo сохраняет логотип в pictures; прежний логотип удаляется.
+func (s *SellerService) SetLogo(ctx context.Context, sellerID int64, data []byte, mime string) (*domain.Picture, error) {
+	if err := checkImage(data, mime, s.maxLogoSize); err != nil {
+		return nil, err
+	}
+	pic := &domain.Picture{MIMEType: mime, SizeBytes: int64(len(data))}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.pictures.Create(ctx, data, mime)
+		if err != nil {
+			return err
+		}
+		pic.ID = id
+		prev, err := s.profiles.SetLogo(ctx, sellerID, &id)
+		if err != nil {
+			return err
+		}
+		return deleteReplacedPicture(ctx, s.pictures, s.audit, "seller_profile", sellerID, prev)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pic, nil
+}
+
+func (s *SellerService) RemoveLogo(ctx context.Context, sellerID int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		prev, err := s.profiles.SetLogo(ctx, sellerID, nil)
+		if err != nil {
+			return err
+		}
+		return deleteReplacedPicture(ctx, s.pictures, s.audit, "seller_profile", sellerID, prev)
+	})
+}
+
+// slugify оставляет латиницу и цифры, остальное схлопывает в дефисы.
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,28 @@
+package service
+
+import "testing"
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Acme Store", "acme-store"},
+		{"  Acme   Store  ", "acme-store"},
+		{"ACME_store!!", "acme-store"},
+		{"--Acme--", "acme"},
+		{"Shop 24/7", "shop-24-7"},
+		{"Лавка Acme", "acme"},
+		{"Café Noir", "caf-noir"}, // не-ASCII буквы выпадают
+		{"Лавка", ""},             // такой slug не пройдёт проверку, его нужно задать явно
+		{"", ""},
+	}
+	for _, tt := range tests {
+		got := slugify(tt.in)
+		if got != tt.want {
+			t.Errorf("slugify(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if len(got) >= 3 && !slugRe.MatchString(got) {
+			t.Errorf("slugify(%q) = %q does not match slugRe", tt.in, got)
+		}
+	}
+}
@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"market/internal/domain"
+	"market/internal/events"
+	"market/internal/mailer"
+	"market/internal/repository"
+	"market/internal/utils"
+)
+
+var ErrInvalidDisplayName = errors.New("display name must be at most 64 characters")
+var ErrInvalidPhone = errors.New("invalid phone, expected international format like +15551234567")
+var ErrEmailUnchanged = errors.New("new email is the same as current")
+var ErrReauthRequired = errors.New("recent login required, sign in again")
+
+type UserConfig struct {
+	// ReauthMaxAge — насколько свежим должен быть вход пользователя без пароля
+	// (только OIDC), чтобы сменить email или удалить учётную запись
+	ReauthMaxAge time.Duration
+}
+
+// Confirmation подтверждает чувствительное действие: пароль, а у пользователей без пароля —
+// недавний вход (AuthTime из access-токена).
+type Confirmation struct {
+	Password string
+	AuthTime time.Time
+	IP       string
+}
+
+const maxDisplayNameLen = 64
+
+// E.164: "+", код страны и до 15 цифр всего
+var phoneRe = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+type UserService struct {
+	users         repository.UserRepository
+	pictures      repository.PictureRepository
+	products      repository.ProductRepository
+	sellers       repository.SellerProfileRepository
+	identities    repository.IdentityRepository
+	mfa           repository.MFARepository
+	refreshTokens repository.RefreshTokenRepository
+	revocation    *RevocationService
+	verification  *EmailVerificationService
+	passwords     *utils.PasswordHasher
+	guard         *LoginGuard
+	mailer        mailer.Mailer
+	jobs          *Background
+	tx            repository.TxManager
+	publisher     EventPublisher
+	audit         *Auditor
+	maxAvatarSize int64
+	cfg           UserConfig
+}
+
+func NewUserService(
+	users repository.UserRepository,
+	pictures repository.PictureRepository,
+	products repository.ProductRepository,
+	sellers repository.SellerProfileRepository,
+	identities repository.IdentityRepository,
+	mfa repository.MFARepository,
+	refreshTokens repository.RefreshTokenRepository,
+	revocation *RevocationService,
+	verification *EmailVerificationService,
+	passwords *utils.PasswordHasher,
+	guard *LoginGuard,
+	m mailer.Mailer,
+	jobs *Background,
+	tx repository.TxManager,
+	publisher EventPublisher,
+	audit *Auditor,
+	cfg UserConfig,
+) *UserService {
+	return &UserService{
+		users:         users,
+		pictures:      pictures,
+		products:      products,
+		sellers:       sellers,
+		identities:    identities,
+		mfa:           mfa,
+		refreshTokens: refreshTokens,
+		revocation:    revocation,
+		verification:  verification,
+		passwords:     passwords,
+		guard:         guard,
+		mailer:        m,
+		jobs:          jobs,
+		tx:            tx,
+		publisher:     publisher,
+		audit:         audit,
+		maxAvatarSize: 2 << 20, // 2 MiB
+		cfg:           cfg,
+	}
+}
+
+// ProfileUpdateInput: отсутствующее поле не меняется, пустая строка очищает его.
+type ProfileUpdateInput struct {
+	DisplayName *string `json:"display_name"`
+	Phone       *string `json:"phone"`
+}
+
+func (s *UserService) Get(ctx context.Context, userID int64) (*domain.User, error) {
+	return s.users.GetByID(ctx, userID)
+}
+
+// profileFields — поля профиля в журнале аудита
+type profileFields struct {
+	DisplayName *string `json:"display_name"`
+	Phone       *string `json:"phone"`
+}
+
+func (s *UserService) UpdateProfile(ctx context.Context, userID int64, in ProfileUpdateInput) (*domain.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := profileFields{DisplayName: u.DisplayName, Phone: u.Phone}
+	if in.DisplayName != nil {
+		name := strings.TrimSpace(*in.DisplayName)
+		if utf8.RuneCountInString(name) > maxDisplayNameLen {
+			return nil, ErrInvalidDisplayName
+		}
+		u.DisplayName = nilIfEmpty(name)
+	}
+	if in.Phone != nil {
+		phone := normalizePhone(*in.Phone)
+		if phone != "" && !phoneRe.MatchString(phone) {
+			return nil, ErrInvalidPhone
+		}
+		u.Phone = nilIfEmpty(phone)
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.UpdateProfile(ctx, userID, u.DisplayName, u.Phone); err != nil {
+			return err
+		}
+		return s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserProfileUpdate, EntityType: "user", EntityID: userID,
+			Before: before,
+			After:  profileFields{DisplayName: u.DisplayName, Phone: u.Phone},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// SetAvatar сохраняет картинку в pictures и делает её аватаром. Прежний аватар удаляется.
+func (s *UserService) SetAvatar(ctx context.Context, userID int64, data []byte, mime string) (*domain.Picture, error) {
+	if err := checkImage(data, mime, s.maxAvatarSize); err != nil {
+		return nil, err
+	}
+	pic := &domain.Picture{MIMEType: mime, SizeBytes: int64(len(data))}
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.pictures.Create(ctx, data, mime)
+		if err != nil {
+			return err
+		}
+		pic.ID = id
+		prev, err := s.users.SetAvatar(ctx, userID, &id)
+		if err != nil {
+			return err
+		}
+		return s.avatarChanged(ctx, userID, prev, &id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pic, nil
+}
+
+func (s *UserService) RemoveAvatar(ctx context.Context, userID int64) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		prev, err := s.users.SetAvatar(ctx, userID, nil)
+		if err != nil || prev == nil {
+			return err
+		}
+		return s.avatarChanged(ctx, userID, prev, nil)
+	})
+}
+
+// avatarChanged пишет смену аватара в журнал и удаляет прежнюю картинку.
+func (s *UserService) avatarChanged(ctx context.Context, userID int64, prev, next *int64) error {
+	if err := s.audit.Record(ctx, AuditRecord{
+		Action: AuditUserProfileUpdate, EntityType: "user", EntityID: userID,
+		Before: map[string]*int64{"avatar_picture_id": prev},
+		After:  map[string]*int64{"avatar_picture_id": next},
+	}); err != nil {
+		return err
+	}
+	return deleteReplacedPicture(ctx, s.pictures, s.audit, "user", userID, prev)
+}
+
+// ChangeEmail запоминает новый адрес и отправляет на него письмо подтверждения.
+// До подтверждения вход и письма работают по прежнему адресу, на который уходит уведомление.
+func (s *UserService) ChangeEmail(ctx context.Context, userID int64, email string, conf Confirmation) error {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if err := mailer.ValidateAddress(email); err != nil {
+		return err
+	}
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirm(ctx, u, conf); err != nil {
+		return err
+	}
+	if email == u.Email {
+		return ErrEmailUnchanged
+	}
+	if _, err := s.users.GetByEmail(ctx, email); err == nil {
+		return repository.ErrEmailTaken
+	} else if !errors.Is(err, repository.ErrUserNotFound) {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.users.SetPendingEmail(ctx, userID, email); err != nil {
+			return err
+		}
+		if err := s.verification.Send(ctx, userID, email); err != nil {
+			return err
+		}
+		if err := s.audit.Record(ctx, AuditRecord{
+			Action: AuditUserEmailChange, EntityType: "user", EntityID: userID,
+			Before: map[string]*string{"pending_email": u.PendingEmail},
+			After:  map[string]string{"pending_email": email},
+		}); err != nil {
+			return err
+		}
+		sendAfterCommit(ctx, s.jobs, s.mailer, mailer.Message{
+			To:      u.Email,
+			Subject: "Смена email",
+			Body: fmt.Sprintf("Запрошена смена адреса учётной записи на %s.\n"+
+				"Если это были не вы, смените пароль.", email),
+		})
+		return nil
+	})
+}
+
+// Delete удаляет учётную запись: персональные данные стираются, сессии завершаются,
+// внешние входы и 2FA отключаются, товары и витрина продавца снимаются с публикации.
+// Строка users остаётся, поэтому история заказов сохраняется.
+func (s *UserService) Delete(ctx context.Context, userID int64, conf Confirmation) error {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.confirm(ctx, u, conf); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		avatar, err := s.users.SoftDelete(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if avatar != nil {
+			if err := s.pictures.DeletePicture(ctx, *avatar); err != nil {
+				return err
+			}
+		}
+		logo, err := s.sellers.Delete(ctx, userID)
+		switch {
+		case err == nil && logo != nil:
+			if err := s.pictures.DeletePicture(ctx, *logo); err != nil {
+				return err
+			}
+		case err != nil && !errors.Is(err, repository.ErrSellerProfileNotFound):
+			return err
+		}
+		if err := s.identities.DeleteForUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.mfa.DeleteTOTP(ctx, userID); err != nil {
+			return err
+		}
+		productIDs, err := s.products.DeleteBySeller(ctx, userID)
+		if err != nil {
+			return err
+		}
+		for _, id := range productIDs {
+			if err := s.publisher.Publish(ctx, events.ProductDeleted{ProductID: id, SellerID: userID}); err != nil {
+				return err
+			}
+		}
+		if err := s.refreshTokens.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+		if err := s.revocation.RevokeAllForUser(ctx, userID); err != nil {
+			return err
+		}
+		// без diff: стёртые персональные данные не должны оседать в журнале
+		if err := s.audit.Record(ctx, AuditRecord{Action: AuditUserDelete, EntityType: "user", EntityID: userID}); err != nil {
+			return err
+		}
+		return s.publisher.Publish(ctx, events.UserDeleted{UserID: userID})
+	})
+}
+
+// confirm проверяет пароль под LoginGuard, как при входе. У пользователей, вошедших
+// только через OIDC, пароля нет: от них требуется вход не раньше ReauthMaxAge назад.
+// Одного access-токена мало — иначе украденный токен позволял бы сменить email
+// на свой и через сброс пароля забрать учётную запись.
+func (s *UserService) confirm(ctx context.Context, u *domain.User, conf Confirmation) error {
+	if u.PasswordHash == "" {
+		if conf.AuthTime.IsZero() || time.Since(conf.AuthTime) > s.cfg.ReauthMaxAge {
+			return ErrReauthRequired
+		}
+		return nil
+	}
+	return s.guard.Guard(ctx, u.Email, conf.IP, func() error {
+		return confirmPassword(s.passwords, u, conf.Password)
+	})
+}
+
+// confirmPassword подтверждает действие паролем. У пользователей, вошедших только
+// через OIDC, пароля нет, и проверка пропускается: вызывающий обязан потребовать
+// другое подтверждение (код 2FA, свежий вход).
+func confirmPassword(passwords *utils.PasswordHasher, u *domain.User, password string) error {
+	if u.PasswordHash == "" {
+		return nil
+	}
+	ok, _, err := passwords.Verify(u.PasswordHash, password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrWrongPassword
+	}
+	return nil
+}
+
+// deleteReplacedPicture удаляет прежнюю картинку профиля (аватар, логотип) и пишет
+// picture.delete в журнал, как PictureService при удалении картинки товара.
+func deleteReplacedPicture(ctx context.Context, pictures repository.PictureRepository, audit *Auditor, entityType string, entityID int64, pictureID *int64) error {
+	if pictureID == nil {
+		return nil
+	}
+	if err := pictures.DeletePicture(ctx, *pictureID); err != nil {
+		return err
+	}
+	return audit.Record(ctx, AuditRecord{
+		Action: AuditPictureDelete, EntityType: entityType, EntityID: entityID,
+		Before: pictureRef{PictureID: *pictureID},
+	})
+}
+
+// checkImage проверяет загружаемую картинку профиля: размер и тип image/*.
+func checkImage(data []byte, mime string, maxSize int64) error {
+	if len(data) == 0 || int64(len(data)) > maxSize {
+		return errors.New("invalid file size")
+	}
+	if !strings.HasPrefix(mime, "image/") {
+		return errors.New("file must be an image")
+	}
+	return nil
+}
+
+func normalizePhone(p string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '(', ')':
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(p))
+}
+
+func nilIfEmpty(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"market/internal/domain"
+)
+
+func TestConfirmPasswordlessRequiresRecentLogin(t *testing.T) {
+	s := &UserService{cfg: UserConfig{ReauthMaxAge: 5 * time.Minute}}
+	u := &domain.User{ID: 1, Email: "oidc@example.com"} // вошёл только через OIDC, пароля нет
+	tests := []struct {
+		name     string
+		authTime time.Time
+		want     error
+	}{
+		{"вход только что", time.Now().Add(-time.Minute), nil},
+		{"вход давно", time.Now().Add(-time.Hour), ErrReauthRequired},
+		{"токен без auth_time", time.Time{}, ErrReauthRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.confirm(context.Background(), u, Confirmation{AuthTime: tt.authTime})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("confirm = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"market/internal/domain"
+	"market/internal/repository"
+)
+
+type WalletService struct {
+	balances repository.BalanceRepository
+}
+
+func NewWalletService(balances repository.BalanceRepository) *WalletService {
+	return &WalletService{balances: balances}
+}
+
+func (s *WalletService) Balance(ctx context.Context, userID int64) (*domain.Wallet, error) {
+	return s.balances.GetByUserID(ctx, userID)
+}
+
+func (s *WalletService) Deposit(ctx context.Context, userID, amount int64) (*domain.Wallet, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	return s.balances.Deposit(ctx, userID, amount)
+}
+
+func (s *WalletService) Withdraw(ctx context.Context, userID, amount int64) (*domain.Wallet, error) {
+	if amount <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+	return s.balances.Withdraw(ctx, userID, amount)
+}
+
+func (s *WalletService) Transfer(ctx context.Context, fromUserID, toUserID, amount int64) error {
+	if amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if fromUserID == toUserID {
+		return errors.New("cannot transfer to self")
+	}
+	return s.balances.Transfer(ctx, fromUserID, toUserID, amount)
+}
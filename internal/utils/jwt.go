@@ -1,40 +1,93 @@
 package utils
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
+// iat, nbf и exp пишутся с миллисекундами (RFC 7519 допускает дробный NumericDate).
+// Иначе токен, выпущенный в ту же секунду, что и водяной знак отзыва, считался бы отозванным.
+func init() {
+	jwt.TimePrecision = time.Millisecond
+}
+
 type AuthClaims struct {
 	UserID int64  `json:"uid"`
 	Role   string `json:"role"`
+	// Purpose пуст у access-токенов; служебные токены (например "mfa") не принимаются вместо access
+	Purpose string `json:"purpose,omitempty"`
+	// AuthTime — когда пользователь вводил учётные данные (OIDC auth_time); при refresh не меняется
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func CreateJWT(userID int64, role string, secret string, ttl time.Duration) (string, error) {
+// JWTOptions — параметры выпуска и проверки access-токенов
+type JWTOptions struct {
+	Issuer   string
+	Audience string
+	TTL      time.Duration
+	// Допустимое расхождение часов при проверке exp/nbf/iat
+	Leeway time.Duration
+}
+
+// CreateJWT выпускает access-токен; нулевой authTime не пишется в токен.
+func CreateJWT(userID int64, role string, authTime time.Time, keys *KeySet, opts JWTOptions) (string, error) {
+	return createJWT(userID, role, "", authTime, keys, opts)
+}
+
+// CreatePurposeJWT выпускает служебный токен с claim purpose.
+func CreatePurposeJWT(userID int64, role, purpose string, keys *KeySet, opts JWTOptions) (string, error) {
+	return createJWT(userID, role, purpose, time.Time{}, keys, opts)
+}
+
+func createJWT(userID int64, role, purpose string, authTime time.Time, keys *KeySet, opts JWTOptions) (string, error) {
 	now := time.Now()
 	claims := AuthClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:  userID,
+		Role:    role,
+		Purpose: purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    opts.Issuer,
+			Subject:   strconv.FormatInt(userID, 10),
+			Audience:  jwt.ClaimStrings{opts.Audience},
 			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(opts.TTL)),
 		},
 	}
-	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return t.SignedString([]byte(secret))
+	if !authTime.IsZero() {
+		claims.AuthTime = jwt.NewNumericDate(authTime)
+	}
+	return keys.sign(claims)
 }
 
-func ParseJWT(tokenStr, secret string) (*AuthClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &AuthClaims{}, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+func ParseJWT(tokenStr string, keys *KeySet, opts JWTOptions) (*AuthClaims, error) {
+	return ParsePurposeJWT(tokenStr, "", keys, opts)
+}
+
+// ParsePurposeJWT проверяет токен и требует, чтобы его purpose совпадал с ожидаемым.
+func ParsePurposeJWT(tokenStr, purpose string, keys *KeySet, opts JWTOptions) (*AuthClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &AuthClaims{}, keys.keyfunc,
+		jwt.WithValidMethods(keys.algorithms()),
+		jwt.WithIssuer(opts.Issuer),
+		jwt.WithAudience(opts.Audience),
+		jwt.WithLeeway(opts.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt())
 	if err != nil {
 		return nil, err
 	}
-	if claims, ok := token.Claims.(*AuthClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*AuthClaims)
+	if !ok || !token.Valid || claims.ID == "" || claims.Purpose != purpose {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	// sub и uid должны указывать на одного пользователя
+	if claims.Subject != strconv.FormatInt(claims.UserID, 10) {
+		return nil, jwt.ErrTokenInvalidSubject
 	}
-	return nil, jwt.ErrTokenInvalidClaims
+	return claims, nil
 }
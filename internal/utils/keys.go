@@ -0,0 +1,226 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeyConfig описывает ключ подписи из конфигурации. Ключ без приватной части
+// используется только для проверки (например, предыдущий ключ во время ротации).
+type KeyConfig struct {
+	ID             string
+	Algorithm      string // RS256 | RS384 | RS512 | EdDSA
+	PrivateKeyFile string
+	PublicKeyFile  string
+}
+
+type signingKey struct {
+	id      string
+	method  jwt.SigningMethod
+	private any // nil — ключ только для проверки
+	public  any // для HMAC — тот же секрет
+}
+
+// KeySet — активный ключ подписи плюс все ключи, которым доверяем при проверке.
+type KeySet struct {
+	active *signingKey
+	keys   map[string]*signingKey
+}
+
+// NewHMACKeySet — набор из одного общего секрета HS256 (без kid).
+func NewHMACKeySet(secret string) *KeySet {
+	k := &signingKey{method: jwt.SigningMethodHS256, private: []byte(secret), public: []byte(secret)}
+	return &KeySet{active: k, keys: map[string]*signingKey{"": k}}
+}
+
+// LoadKeySet читает PEM-ключи; activeID — kid ключа, которым подписываются новые токены.
+func LoadKeySet(cfgs []KeyConfig, activeID string) (*KeySet, error) {
+	ks := &KeySet{keys: map[string]*signingKey{}}
+	for _, c := range cfgs {
+		if c.ID == "" {
+			return nil, errors.New("jwt key: id required")
+		}
+		if _, dup := ks.keys[c.ID]; dup {
+			return nil, fmt.Errorf("jwt key %q: duplicate id", c.ID)
+		}
+		k, err := loadKey(c)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", c.ID, err)
+		}
+		ks.keys[c.ID] = k
+	}
+	active, ok := ks.keys[activeID]
+	if !ok {
+		return nil, fmt.Errorf("jwt key: active key %q not configured", activeID)
+	}
+	if active.private == nil {
+		return nil, fmt.Errorf("jwt key %q: active key needs a private key", activeID)
+	}
+	ks.active = active
+	return ks, nil
+}
+
+func loadKey(c KeyConfig) (*signingKey, error) {
+	k := &signingKey{id: c.ID}
+	switch c.Algorithm {
+	case "RS256":
+		k.method = jwt.SigningMethodRS256
+	case "RS384":
+		k.method = jwt.SigningMethodRS384
+	case "RS512":
+		k.method = jwt.SigningMethodRS512
+	case "EdDSA":
+		k.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", c.Algorithm)
+	}
+
+	if c.PrivateKeyFile != "" {
+		block, err := readPEM(c.PrivateKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		var priv any
+		if block.Type == "RSA PRIVATE KEY" {
+			priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		} else {
+			priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := priv.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("private key is not a signer")
+		}
+		k.private = priv
+		k.public = signer.Public()
+	}
+	if c.PublicKeyFile != "" {
+		block, err := readPEM(c.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if block.Type == "RSA PUBLIC KEY" {
+			k.public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		} else {
+			k.public, err = x509.ParsePKIXPublicKey(block.Bytes)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if k.public == nil {
+		return nil, errors.New("privateKeyFile or publicKeyFile required")
+	}
+
+	// Тип ключа обязан соответствовать алгоритму
+	switch k.public.(type) {
+	case *rsa.PublicKey:
+		if k.method == jwt.SigningMethodEdDSA {
+			return nil, errors.New("RSA key configured for EdDSA")
+		}
+	case ed25519.PublicKey:
+		if k.method != jwt.SigningMethodEdDSA {
+			return nil, fmt.Errorf("Ed25519 key configured for %s", c.Algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", k.public)
+	}
+	return k, nil
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block", path)
+	}
+	return block, nil
+}
+
+// sign подписывает claims активным ключом и проставляет kid.
+func (ks *KeySet) sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(ks.active.method, claims)
+	if ks.active.id != "" {
+		t.Header["kid"] = ks.active.id
+	}
+	return t.SignedString(ks.active.private)
+}
+
+// keyfunc выбирает ключ проверки по kid и требует, чтобы alg токена совпадал с алгоритмом ключа.
+func (ks *KeySet) keyfunc(t *jwt.Token) (any, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := ks.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != k.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q", t.Method.Alg())
+	}
+	return k.public, nil
+}
+
+func (ks *KeySet) algorithms() []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, k := range ks.keys {
+		if alg := k.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			out = append(out, alg)
+		}
+	}
+	return out
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает публичные ключи в формате RFC 7517. Секрет HMAC не публикуется.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	for _, k := range ks.keys {
+		jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		out.Keys = append(out.Keys, jwk)
+	}
+	sort.Slice(out.Keys, func(i, j int) bool { return out.Keys[i].Kid < out.Keys[j].Kid })
+	return out
+}
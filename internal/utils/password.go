@@ -0,0 +1,251 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrWeakPassword — пароль не проходит политику; конкретная причина в тексте ошибки.
+var ErrWeakPassword = errors.New("weak password")
+
+var errUnknownHash = errors.New("unknown password hash format")
+
+const (
+	AlgArgon2id = "argon2id"
+	AlgBcrypt   = "bcrypt"
+
+	// bcrypt молча отбрасывает всё после 72 байт
+	bcryptMaxBytes = 72
+)
+
+type Argon2Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type PasswordConfig struct {
+	// Алгоритм новых хэшей: argon2id (по умолчанию) или bcrypt. Проверяются оба.
+	Algorithm  string
+	BcryptCost int
+	Argon2     Argon2Params
+
+	MinLength int // в символах
+	MaxLength int // в байтах
+	// Файл утёкших паролей: по одному в строке, открытым текстом или SHA-1 в hex
+	// (формат HIBP "HASH:count" тоже подходит)
+	BreachedListFile string
+}
+
+// PasswordHasher хэширует пароли, проверяет их по политике и подсказывает,
+// когда сохранённый хэш пора пересчитать.
+type PasswordHasher struct {
+	cfg      PasswordConfig
+	breached map[string]struct{}
+	dummy    string
+}
+
+func NewPasswordHasher(cfg PasswordConfig) (*PasswordHasher, error) {
+	switch cfg.Algorithm {
+	case "":
+		cfg.Algorithm = AlgArgon2id
+	case AlgArgon2id, AlgBcrypt:
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm %q", cfg.Algorithm)
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.DefaultCost
+	}
+	if cfg.BcryptCost < bcrypt.MinCost || cfg.BcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("bcrypt cost %d out of range", cfg.BcryptCost)
+	}
+	defaults := Argon2Params{Memory: 64 * 1024, Iterations: 3, Parallelism: 2, SaltLength: 16, KeyLength: 32}
+	if cfg.Argon2.Memory == 0 {
+		cfg.Argon2.Memory = defaults.Memory
+	}
+	if cfg.Argon2.Iterations == 0 {
+		cfg.Argon2.Iterations = defaults.Iterations
+	}
+	if cfg.Argon2.Parallelism == 0 {
+		cfg.Argon2.Parallelism = defaults.Parallelism
+	}
+	if cfg.Argon2.SaltLength == 0 {
+		cfg.Argon2.SaltLength = defaults.SaltLength
+	}
+	if cfg.Argon2.KeyLength == 0 {
+		cfg.Argon2.KeyLength = defaults.KeyLength
+	}
+	if cfg.Algorithm == AlgBcrypt && (cfg.MaxLength == 0 || cfg.MaxLength > bcryptMaxBytes) {
+		cfg.MaxLength = bcryptMaxBytes
+	}
+	h := &PasswordHasher{cfg: cfg, breached: map[string]struct{}{}}
+	if cfg.BreachedListFile != "" {
+		if err := h.loadBreached(cfg.BreachedListFile); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if h.dummy, err = h.hash("market-dummy-password"); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *PasswordHasher) loadBreached(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if hash, _, _ := strings.Cut(line, ":"); len(hash) == 40 && isHex(hash) {
+			h.breached[strings.ToUpper(hash)] = struct{}{}
+			continue
+		}
+		h.breached[sha1Hex(line)] = struct{}{}
+	}
+	return sc.Err()
+}
+
+// Validate проверяет новый пароль по политике.
+func (h *PasswordHasher) Validate(pw string) error {
+	if h.cfg.MinLength > 0 && utf8.RuneCountInString(pw) < h.cfg.MinLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, h.cfg.MinLength)
+	}
+	if h.cfg.MaxLength > 0 && len(pw) > h.cfg.MaxLength {
+		return fmt.Errorf("%w: must be at most %d bytes", ErrWeakPassword, h.cfg.MaxLength)
+	}
+	if len(h.breached) > 0 {
+		_, exact := h.breached[sha1Hex(pw)]
+		_, lower := h.breached[sha1Hex(strings.ToLower(pw))]
+		if exact || lower {
+			return fmt.Errorf("%w: found in a list of breached passwords", ErrWeakPassword)
+		}
+	}
+	return nil
+}
+
+// Hash проверяет пароль по политике и хэширует его текущим алгоритмом.
+func (h *PasswordHasher) Hash(pw string) (string, error) {
+	if err := h.Validate(pw); err != nil {
+		return "", err
+	}
+	return h.hash(pw)
+}
+
+// Rehash пересчитывает хэш уже проверенного пароля без проверки политики —
+// старый пароль мог быть задан до её ужесточения.
+func (h *PasswordHasher) Rehash(pw string) (string, error) {
+	return h.hash(pw)
+}
+
+// Verify сверяет пароль с хэшем. needsRehash — хэш сделан другим алгоритмом
+// или с устаревшими параметрами.
+func (h *PasswordHasher) Verify(encoded, pw string) (ok, needsRehash bool, err error) {
+	switch {
+	case encoded == "":
+		// Пароль не задан (например, вход только через OIDC)
+		h.VerifyDummy(pw)
+		return false, false, nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		p, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(pw), salt, p.Iterations, p.Memory, p.Parallelism, uint32(len(key)))
+		ok = subtle.ConstantTimeCompare(got, key) == 1
+		cur := h.cfg.Argon2
+		needsRehash = h.cfg.Algorithm != AlgArgon2id ||
+			p.Memory != cur.Memory || p.Iterations != cur.Iterations || p.Parallelism != cur.Parallelism ||
+			uint32(len(key)) != cur.KeyLength
+		return ok, needsRehash, nil
+	case strings.HasPrefix(encoded, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(pw)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return false, false, err
+		}
+		return true, h.cfg.Algorithm != AlgBcrypt || cost != h.cfg.BcryptCost, nil
+	}
+	return false, false, errUnknownHash
+}
+
+// VerifyDummy тратит на проверку столько же времени, сколько Verify настоящего
+// хэша. Вызывается, когда пользователь не найден.
+func (h *PasswordHasher) VerifyDummy(pw string) {
+	_, _, _ = h.Verify(h.dummy, pw)
+}
+
+func (h *PasswordHasher) hash(pw string) (string, error) {
+	if h.cfg.Algorithm == AlgBcrypt {
+		b, err := bcrypt.GenerateFromPassword([]byte(pw), h.cfg.BcryptCost)
+		return string(b), err
+	}
+	p := h.cfg.Argon2
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(pw), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// decodeArgon2 разбирает PHC-строку $argon2id$v=19$m=...,t=...,p=...$salt$key
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, errUnknownHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, errUnknownHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, errUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, errUnknownHash
+	}
+	return p, salt, key, nil
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Маленькие параметры, чтобы тесты шли быстро
+var testArgon2 = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1}
+
+func newTestHasher(t *testing.T, cfg PasswordConfig) *PasswordHasher {
+	t.Helper()
+	if cfg.Argon2 == (Argon2Params{}) {
+		cfg.Argon2 = testArgon2
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = bcrypt.MinCost
+	}
+	h, err := NewPasswordHasher(cfg)
+	if err != nil {
+		t.Fatalf("NewPasswordHasher: %v", err)
+	}
+	return h
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	const pw = "correct horse battery"
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon := newTestHasher(t, PasswordConfig{Algorithm: AlgArgon2id})
+	argonHash, err := argon.Hash(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	strongerArgon := newTestHasher(t, PasswordConfig{Algorithm: AlgArgon2id, Argon2: Argon2Params{Memory: 2048, Iterations: 1, Parallelism: 1}})
+	bcrypter := newTestHasher(t, PasswordConfig{Algorithm: AlgBcrypt})
+	costlierBcrypt := newTestHasher(t, PasswordConfig{Algorithm: AlgBcrypt, BcryptCost: bcrypt.MinCost + 1})
+
+	tests := []struct {
+		name        string
+		hasher      *PasswordHasher
+		encoded     string
+		pw          string
+		wantOK      bool
+		wantRehash  bool
+		wantErrType error
+	}{
+		{"argon2id: верный пароль", argon, argonHash, pw, true, false, nil},
+		{"argon2id: неверный пароль", argon, argonHash, "wrong", false, false, nil},
+		{"argon2id: параметры устарели", strongerArgon, argonHash, pw, true, true, nil},
+		{"argon2id при алгоритме bcrypt", bcrypter, argonHash, pw, true, true, nil},
+		{"bcrypt -> argon2id", argon, string(bcryptHash), pw, true, true, nil},
+		{"bcrypt: неверный пароль", argon, string(bcryptHash), "wrong", false, false, nil},
+		{"bcrypt: тот же cost", bcrypter, string(bcryptHash), pw, true, false, nil},
+		{"bcrypt: cost вырос", costlierBcrypt, string(bcryptHash), pw, true, true, nil},
+		{"пароль не задан", argon, "", pw, false, false, nil},
+		{"неизвестный формат", argon, "$md5$abc", pw, false, false, errUnknownHash},
+		{"битая PHC-строка", argon, "$argon2id$v=19$m=1024", pw, false, false, errUnknownHash},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, rehash, err := tt.hasher.Verify(tt.encoded, tt.pw)
+			if !errors.Is(err, tt.wantErrType) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErrType)
+			}
+			if ok != tt.wantOK || rehash != tt.wantRehash {
+				t.Fatalf("ok, needsRehash = %v, %v, want %v, %v", ok, rehash, tt.wantOK, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherRehashRoundTrip(t *testing.T) {
+	const pw = "correct horse battery"
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := newTestHasher(t, PasswordConfig{Algorithm: AlgArgon2id})
+	if _, rehash, _ := h.Verify(string(bcryptHash), pw); !rehash {
+		t.Fatal("bcrypt-хэш должен требовать пересчёта")
+	}
+	fresh, err := h.Rehash(pw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, rehash, err := h.Verify(fresh, pw)
+	if err != nil || !ok || rehash {
+		t.Fatalf("после пересчёта: ok=%v needsRehash=%v err=%v", ok, rehash, err)
+	}
+}
+
+func TestPasswordHasherValidate(t *testing.T) {
+	h := newTestHasher(t, PasswordConfig{MinLength: 8, MaxLength: 16})
+	tests := []struct {
+		pw      string
+		wantErr bool
+	}{
+		{"short", true},
+		{"exactly8", false},
+		{"пароль12", false}, // длина в символах, а не в байтах
+		{"this-is-too-long-password", true},
+	}
+	for _, tt := range tests {
+		err := h.Validate(tt.pw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Validate(%q) = %v, wantErr %v", tt.pw, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("Validate(%q) = %v, want ErrWeakPassword", tt.pw, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewOpaqueToken генерирует случайный токен (256 бит) и его хэш для хранения в БД.
+func NewOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken — sha256 в hex. Для случайных токенов достаточно быстрого хэша:
+// перебор по словарю не имеет смысла.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix отличает API-ключи от прочих секретов (например, в сканерах утечек).
+const APIKeyPrefix = "mk_"
+
+// NewAPIKey генерирует ключ вида mk_<id>_<secret>. id — 8 hex-символов, по нему
+// ключ узнаётся в списке; в БД хранится только хэш всего ключа.
+func NewAPIKey() (key, prefix, hash string, err error) {
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret, _, err := NewOpaqueToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	prefix = APIKeyPrefix + hex.EncodeToString(id)
+	key = prefix + "_" + secret
+	return key, prefix, HashToken(key), nil
+}
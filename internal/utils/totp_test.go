@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Секрет из RFC 6238 (приложение B) для SHA1: ASCII "12345678901234567890"
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFC6238(t *testing.T) {
+	// В RFC коды 8-значные; 6-значный код — последние 6 цифр
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("T=%d: step = %d, want %d", tt.unix, step, want)
+		}
+	}
+}
+
+func TestValidateTOTPSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code := "005924" // шаг 41152263
+	tests := []struct {
+		name   string
+		offset time.Duration
+		wantOK bool
+	}{
+		{"тот же шаг", 0, true},
+		{"шаг назад", -totpPeriod * time.Second, true},
+		{"шаг вперёд", totpPeriod * time.Second, true},
+		{"два шага назад", -2 * totpPeriod * time.Second, false},
+		{"два шага вперёд", 2 * totpPeriod * time.Second, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(rfc6238Secret, code, now.Add(tt.offset)); ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPInvalidInput(t *testing.T) {
+	now := time.Unix(59, 0)
+	tests := []struct {
+		name, secret, code string
+	}{
+		{"неверный код", rfc6238Secret, "000000"},
+		{"короткий код", rfc6238Secret, "28708"},
+		{"8-значный код", rfc6238Secret, "94287082"},
+		{"битый секрет", "not-base32!", "287082"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now); ok {
+				t.Fatal("code accepted")
+			}
+		})
+	}
+	// Секрет из приложения часто вводят строчными буквами и с пробелами по краям
+	if _, ok := ValidateTOTP(" "+strings.ToLower(rfc6238Secret)+" ", "287082", now); !ok {
+		t.Fatal("lowercase secret rejected")
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"ABCDE-FGHIJ", "ABCDE-FGHIJ"},
+		{"abcde-fghij", "ABCDE-FGHIJ"},
+		{"abcdefghij", "ABCDE-FGHIJ"},
+		{"  ABCDE FGHIJ ", "ABCDE-FGHIJ"},
+		{"abc", "ABC"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRecoveryCodeRoundTrip(t *testing.T) {
+	code, err := NewRecoveryCode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(code) != 11 || code[5] != '-' {
+		t.Fatalf("unexpected format %q", code)
+	}
+	if got := NormalizeRecoveryCode(strings.ToLower(strings.ReplaceAll(code, "-", ""))); got != code {
+		t.Fatalf("normalized %q, want %q", got, code)
+	}
+}
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS ux_users_balance_id;
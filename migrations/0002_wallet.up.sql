@@ -0,0 +1,2 @@
+-- Один кошелёк на пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS ux_users_balance_id ON users(balance_id) WHERE balance_id IS NOT NULL;
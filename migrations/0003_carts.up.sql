@@ -0,0 +1,23 @@
+-- Корзины покупателей: одна на пользователя
+CREATE TABLE IF NOT EXISTS carts (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS cart_items (
+    cart_id     BIGINT NOT NULL REFERENCES carts(id) ON DELETE CASCADE,
+    product_id  BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity    INTEGER NOT NULL CHECK (quantity > 0),
+    added_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (cart_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cart_items_product_id ON cart_items(product_id);
+
+DROP TRIGGER IF EXISTS trg_carts_set_updated_at ON carts;
+CREATE TRIGGER trg_carts_set_updated_at
+    BEFORE UPDATE ON carts
+    FOR EACH ROW
+    EXECUTE FUNCTION set_updated_at();
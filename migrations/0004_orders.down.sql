@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_orders_set_updated_at ON orders;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
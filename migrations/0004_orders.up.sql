@@ -0,0 +1,30 @@
+-- Заказы: один заказ на продавца в рамках одного checkout
+CREATE TABLE IF NOT EXISTS orders (
+    id           BIGSERIAL PRIMARY KEY,
+    buyer_id     BIGINT NOT NULL REFERENCES users(id),
+    seller_id    BIGINT NOT NULL REFERENCES users(id),
+    status       TEXT NOT NULL DEFAULT 'paid',
+    total_cents  BIGINT NOT NULL CHECK (total_cents >= 0),
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Позиции заказа хранят снимок названия и цены на момент покупки
+CREATE TABLE IF NOT EXISTS order_items (
+    id           BIGSERIAL PRIMARY KEY,
+    order_id     BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id   BIGINT REFERENCES products(id) ON DELETE SET NULL,
+    name         TEXT NOT NULL,
+    price_cents  BIGINT NOT NULL CHECK (price_cents >= 0),
+    quantity     INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_buyer_id ON orders(buyer_id);
+CREATE INDEX IF NOT EXISTS idx_orders_seller_id ON orders(seller_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items(order_id);
+
+DROP TRIGGER IF EXISTS trg_orders_set_updated_at ON orders;
+CREATE TRIGGER trg_orders_set_updated_at
+    BEFORE UPDATE ON orders
+    FOR EACH ROW
+    EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS order_status_history;
+DROP INDEX IF EXISTS idx_orders_seller_status;
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_status;
@@ -0,0 +1,17 @@
+ALTER TABLE orders
+    ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled', 'refunded'));
+
+CREATE INDEX IF NOT EXISTS idx_orders_seller_status ON orders(seller_id, status);
+
+-- История смен статусов заказа
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id           BIGSERIAL PRIMARY KEY,
+    order_id     BIGINT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status  TEXT,
+    to_status    TEXT NOT NULL,
+    changed_by   BIGINT REFERENCES users(id) ON DELETE SET NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id);
@@ -0,0 +1,14 @@
+-- Transactional outbox: события пишутся в той же транзакции, что и бизнес-изменение,
+-- и затем доставляются в Kafka релеем (at-least-once).
+CREATE TABLE IF NOT EXISTS outbox (
+    id            BIGSERIAL PRIMARY KEY,
+    event_type    TEXT NOT NULL,
+    aggregate_id  TEXT NOT NULL,
+    payload       JSONB NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at       TIMESTAMPTZ,
+    attempts      INTEGER NOT NULL DEFAULT 0,
+    last_error    TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent ON outbox(id) WHERE sent_at IS NULL;
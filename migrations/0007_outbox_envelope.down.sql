@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ux_outbox_event_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS event_version;
+ALTER TABLE outbox DROP COLUMN IF EXISTS event_id;
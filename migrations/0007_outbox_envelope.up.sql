@@ -0,0 +1,4 @@
+-- payload теперь хранит конверт events.Envelope; id и версия события дублируются для заголовков
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS event_id UUID;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS event_version INTEGER NOT NULL DEFAULT 1;
+CREATE UNIQUE INDEX IF NOT EXISTS ux_outbox_event_id ON outbox(event_id);
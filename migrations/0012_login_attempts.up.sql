@@ -0,0 +1,7 @@
+-- Неудачные попытки входа по ключу "email:<адрес>" или "ip:<адрес>"
+CREATE TABLE IF NOT EXISTS login_attempts (
+    key              TEXT PRIMARY KEY,
+    failures         INTEGER NOT NULL DEFAULT 0,
+    last_failure_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    blocked_until    TIMESTAMPTZ
+);
@@ -0,0 +1,17 @@
+-- TOTP: секрет хранится зашифрованным (AES-GCM), enabled_at = NULL — подключение не подтверждено
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id           BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted  BYTEA NOT NULL,
+    enabled_at        TIMESTAMPTZ,
+    last_used_step    BIGINT NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Одноразовые коды восстановления; храним sha256-хэш
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash   TEXT NOT NULL,
+    used_at     TIMESTAMPTZ,
+    UNIQUE (user_id, code_hash)
+);
@@ -0,0 +1,23 @@
+-- Внешние учётные записи (OIDC): провайдер + subject -> пользователь
+CREATE TABLE IF NOT EXISTS identities (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider    TEXT NOT NULL,
+    subject     TEXT NOT NULL,
+    email       TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_identities_user_id ON identities(user_id);
+
+-- Незавершённые входы: state, nonce и PKCE code_verifier между /login и /callback
+CREATE TABLE IF NOT EXISTS oidc_states (
+    state          TEXT PRIMARY KEY,
+    provider       TEXT NOT NULL,
+    nonce          TEXT NOT NULL,
+    code_verifier  TEXT NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_oidc_states_expires_at ON oidc_states(expires_at);
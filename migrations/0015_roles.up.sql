@@ -0,0 +1,4 @@
+-- Роли персонала: модератор и администратор
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check;
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('buyer', 'seller', 'moderator', 'admin'));
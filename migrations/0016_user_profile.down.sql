@@ -0,0 +1,6 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS pending_email,
+    DROP COLUMN IF EXISTS avatar_picture_id,
+    DROP COLUMN IF EXISTS phone,
+    DROP COLUMN IF EXISTS display_name;
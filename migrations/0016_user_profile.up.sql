@@ -0,0 +1,8 @@
+-- Профиль пользователя, смена email и мягкое удаление
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS display_name      TEXT,
+    ADD COLUMN IF NOT EXISTS phone             TEXT,
+    ADD COLUMN IF NOT EXISTS avatar_picture_id BIGINT REFERENCES pictures(id) ON DELETE SET NULL,
+    -- новый адрес ждёт подтверждения, текущий email действует до него
+    ADD COLUMN IF NOT EXISTS pending_email     TEXT,
+    ADD COLUMN IF NOT EXISTS deleted_at        TIMESTAMPTZ;
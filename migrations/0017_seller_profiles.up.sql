@@ -0,0 +1,12 @@
+-- Витрина продавца: публичный профиль магазина по slug
+CREATE TABLE IF NOT EXISTS seller_profiles (
+    user_id          BIGINT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    slug             TEXT NOT NULL UNIQUE,
+    shop_name        TEXT NOT NULL,
+    description      TEXT NOT NULL DEFAULT '',
+    logo_picture_id  BIGINT REFERENCES pictures(id) ON DELETE SET NULL,
+    contact_email    TEXT,
+    contact_phone    TEXT,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
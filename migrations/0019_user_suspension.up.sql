@@ -0,0 +1,8 @@
+-- Блокировка пользователей персоналом
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS suspended_at      TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS suspension_reason TEXT,
+    ADD COLUMN IF NOT EXISTS suspended_by      BIGINT REFERENCES users(id) ON DELETE SET NULL;
+
+-- Публичные выборки товаров отсекают заблокированных продавцов
+CREATE INDEX IF NOT EXISTS idx_users_suspended ON users(id) WHERE suspended_at IS NOT NULL;
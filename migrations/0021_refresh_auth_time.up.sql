@@ -0,0 +1,4 @@
+-- Время последнего ввода учётных данных в сессии: переносится при ротации refresh-токена
+-- и попадает в claim auth_time access-токена. NULL — сессии, начатые до миграции.
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS authenticated_at TIMESTAMPTZ;